@@ -10,4 +10,9 @@ require (
 	github.com/lrstanley/x/sync v0.0.0-20260505072934-f1321f6fa876
 )
 
-require github.com/lmittmann/tint v1.1.3 // indirect
+require (
+	github.com/lmittmann/tint v1.1.3 // indirect
+	go.yaml.in/yaml/v3 v3.0.4 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/term v0.45.0 // indirect
+)
@@ -20,7 +20,7 @@ type Flags struct {
 		Recursive bool          `help:"Recursively remove files." short:"r"`
 		Delay     time.Duration `help:"testing time.Duration." default:"1s"`
 		Paths     []string      `arg:"" help:"Paths to remove." type:"path" name:"path"`
-	} `cmd:"" help:"Remove files."`
+	} `cmd:"" help:"Remove files." examples:"rm -rf ./build # remove a directory and its contents;rm --user=root ./file.txt"`
 
 	LS struct {
 		Paths []string `arg:"" optional:"" help:"Paths to list." placeholder:"<paths>" type:"path"`
@@ -8,7 +8,8 @@
 // application.
 //
 // This example also shows how to break up your flags into different groups,
-// which could be pulled from other sub-packages.
+// which could be pulled from other sub-packages, and how to hot reload flags
+// when the ".env" file changes (or when receiving SIGHUP).
 package main
 
 import (
@@ -25,11 +26,21 @@ type Flags struct {
 	Service *ServiceConfig `embed:"" prefix:"service." envprefix:"SERVICE_" group:"Service flags"`
 }
 
-var cli = clix.NewWithDefaults[Flags]()
+var cli = clix.NewWithDefaults(
+	clix.WithReloadPlugin[Flags](5 * time.Second),
+)
 
 func main() {
 	logger := cli.GetLogger()
 
+	cli.OnReload(func(prev, next *Flags) {
+		logger.Info(
+			"flags reloaded",
+			"old_interval", prev.Service.Interval,
+			"new_interval", next.Service.Interval,
+		)
+	})
+
 	ctx := context.TODO()
 
 	// This is an example of using the github.com/lrstanley/x/scheduler package
@@ -22,11 +22,12 @@ func WithAppInfo[T any](app AppInfo) Option[T] {
 }
 
 type AppInfo struct {
-	Name        string `json:"name"`          // Application name. Defaults to the main module path.
-	Description string `json:"description"`   // Application description.
-	Version     string `json:"build_version"` // Build version. Uses VCS info if available.
-	Commit      string `json:"build_commit"`  // VCS commit SHA. Uses VCS info if available.
-	Date        string `json:"build_date"`    // VCS commit date. Uses VCS info if available.
+	Name        string `json:"name"`                   // Application name. Defaults to the main module path.
+	Description string `json:"description"`            // Application description.
+	Version     string `json:"build_version"`          // Build version. Uses VCS info if available.
+	Commit      string `json:"build_commit"`           // VCS commit SHA. Uses VCS info if available.
+	Date        string `json:"build_date"`             // VCS commit date. Uses VCS info if available.
+	Branch      string `json:"build_branch,omitempty"` // VCS branch. Only available when provided through ldflags.
 
 	Links []Link `json:"links,omitempty"` // Links to the project's website, support, issues, security, etc.
 }
@@ -48,6 +49,9 @@ func (a *AppInfo) Inherit(app AppInfo) {
 	if app.Date != "" {
 		a.Date = app.Date
 	}
+	if app.Branch != "" {
+		a.Branch = app.Branch
+	}
 	if len(app.Links) > 0 {
 		a.Links = app.Links
 	}
@@ -5,6 +5,9 @@
 package clix
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strconv"
@@ -45,13 +48,21 @@ func WithGithubDebug[T any]() Option[T] {
 
 // CLI is the main construct for clix, obtained via [New] or [NewWithDefaults].
 type CLI[T any] struct {
-	kong.Plugins                           // Kong-specific plugins.
-	kongOptions       []kong.Option        `kong:"-"`
-	version           *Version             `kong:"-"`
-	app               *AppInfo             `kong:"-"`
-	logHandler        slog.Handler         `kong:"-"`
-	logHandlerOptions *slog.HandlerOptions `kong:"-"`
-	logger            *slog.Logger         `kong:"-"`
+	kong.Plugins                            // Kong-specific plugins.
+	kongOptions        []kong.Option        `kong:"-"`
+	version            *Version             `kong:"-"`
+	app                *AppInfo             `kong:"-"`
+	logHandler         slog.Handler         `kong:"-"`
+	logHandlerOptions  *slog.HandlerOptions `kong:"-"`
+	logger             *slog.Logger         `kong:"-"`
+	envFiles           *envFileLoader       `kong:"-"`
+	reloader           *reloader[T]         `kong:"-"`
+	output             *OutputPlugin        `kong:"-"`
+	logging            *LoggingPlugin       `kong:"-"`
+	markdown           *MarkdownOptions     `kong:"-"`
+	strictDeprecations bool                 `kong:"-"`
+	lookupEnv          lookupEnvFunc        `kong:"-"` // See [CLI.getenv].
+	stderr             *progressWriter      `kong:"-"` // See [CLI.errWriter].
 
 	// Context is the context returned by kong after initial parsing.
 	Context *kong.Context `kong:"-"`
@@ -62,6 +73,9 @@ type CLI[T any] struct {
 	// Debug can be used to enable/disable debugging as a global flag. Also
 	// sets the log level to debug.
 	Debug bool `short:"D" name:"debug" help:"enables debug mode"`
+
+	// Color controls when colors are used in output. See [CLI.Term].
+	Color string `name:"color" default:"auto" enum:"auto,always,never" help:"when to use colors in output"`
 }
 
 // New executes the cli parser, with the provided options (no defaults are
@@ -75,14 +89,17 @@ func New[T any](options ...Option[T]) *CLI[T] {
 
 	cli.app = &AppInfo{}
 	cli.version = GetVersionInfo(cli.app)
+	cli.markdown = &MarkdownOptions{}
 	cli.kongOptions = []kong.Option{
 		kong.ConfigureHelp(kong.HelpOptions{
 			Tree:      true,
 			FlagsLast: true,
 		}),
 		kong.UsageOnError(),
+		kong.Help(helpPrinter),
 		kong.Bind(cli.version),
 		kong.Bind(cli.app),
+		kong.Bind(cli.markdown),
 		kong.Bind(cli),
 	}
 
@@ -139,3 +156,100 @@ func NewWithDefaults[T any](options ...Option[T]) *CLI[T] {
 		)...,
 	)
 }
+
+// helpPrinter wraps [kong.DefaultHelpPrinter], appending any examples of the
+// selected command (see [Exampler]), and styling headings when colors are enabled
+// for stdout.
+func helpPrinter(options kong.HelpOptions, kctx *kong.Context) error {
+	t := terminalFromContext(kctx)
+	t.stdout, _ = kctx.Stdout.(*os.File)
+
+	var examples []Example
+	if !options.Summary {
+		node := kctx.Selected()
+		if node == nil {
+			node = kctx.Model.Node
+		}
+		examples = NodeExamples(node)
+	}
+
+	if !t.StdoutColor() {
+		if err := kong.DefaultHelpPrinter(options, kctx); err != nil {
+			return err
+		}
+		return writeExamples(kctx.Stdout, kctx.Model.Name, examples)
+	}
+
+	// Kong can't detect the terminal width when writing to a buffer (falling back
+	// to COLUMNS, or 80), so make sure it doesn't exceed the width of the actual
+	// terminal.
+	if w := t.Width(); options.WrapUpperBound <= 0 || options.WrapUpperBound > w {
+		options.WrapUpperBound = w
+	}
+
+	out := kctx.Stdout
+	buf := &bytes.Buffer{}
+	kctx.Stdout = buf
+	defer func() { kctx.Stdout = out }()
+
+	if err := kong.DefaultHelpPrinter(options, kctx); err != nil {
+		return err
+	}
+
+	if err := writeExamples(buf, kctx.Model.Name, examples); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, styleHeadings(buf.String(), true))
+	return err
+}
+
+// lookupEnvFunc looks up an environment variable, like [os.LookupEnv].
+type lookupEnvFunc func(key string) (string, bool)
+
+// getenv looks up an environment variable. While reloading (see [CLI.Reload]),
+// this includes changes to env files which haven't been applied to the
+// environment yet.
+func (cli *CLI[T]) getenv(key string) (string, bool) {
+	if cli.lookupEnv != nil {
+		return cli.lookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+// earlyFlagValue returns the value of the named flag of the node, for use in
+// hooks which run before kong resets and applies values (e.g. BeforeReset, so
+// commands can run without validating the rest of the model). The value is
+// resolved from the command line, then the flag's environment variables, then
+// its default.
+//
+// Kong matches flags of parent nodes first, so if a parent has a flag with the
+// same name (e.g. --output of [WithOutputPlugin]), it is used when provided
+// after the node's command on the command line.
+func earlyFlagValue(kctx *kong.Context, node *kong.Node, name string) string {
+	for _, flag := range node.Flags {
+		if flag.Name != name {
+			continue
+		}
+
+		var scoped bool
+		for _, trace := range kctx.Path {
+			if trace.Command == node {
+				scoped = true
+			}
+
+			if trace.Flag == flag || (scoped && trace.Flag != nil && trace.Flag.Name == name && !trace.Resolved) {
+				return fmt.Sprintf("%v", kctx.FlagValue(trace.Flag))
+			}
+		}
+
+		for _, env := range flag.Tag.Envs {
+			if v, ok := os.LookupEnv(env); ok {
+				return v
+			}
+		}
+
+		return flag.Default
+	}
+	return ""
+}
@@ -5,6 +5,7 @@
 package clix
 
 import (
+	"log/slog"
 	"os"
 	"strings"
 	"testing"
@@ -39,3 +40,36 @@ func TestWithAppInfoVersionInHelp(t *testing.T) {
 		t.Fatalf("expected help not to show (devel) when AppInfo.Version is set, got:\n%s", help)
 	}
 }
+
+type testLogHandlerCmd struct{}
+
+func (testLogHandlerCmd) Run(handler *slog.TextHandler, logger *slog.Logger) error {
+	logger.Info("from command")
+	return nil
+}
+
+func TestWithLogHandlerBindings(t *testing.T) {
+	type Flags struct {
+		Run testLogHandlerCmd `cmd:""`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "run"}
+
+	var buf strings.Builder
+	cli := New(
+		WithKongOptions[Flags](kong.Exit(func(int) { t.Fatal("unexpected exit") })),
+		WithLogHandler[Flags](slog.NewTextHandler(&buf, nil)),
+		WithLoggingPlugin[Flags](false, nil),
+	)
+
+	// Handlers are bound like the ones created by the logging plugin.
+	if err := cli.Context.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "from command") {
+		t.Fatalf("expected command to log through the provided handler, got %q", buf.String())
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package clixtest provides helpers for driving clix CLIs in-process from tests,
+// capturing their output, log records and exit codes, with an isolated
+// environment and working directory. See [Run].
+package clixtest
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/lrstanley/clix/v2"
+)
+
+// AppName is the application name (os.Args[0]) used by [Run]. It can be
+// overridden with [clix.WithAppInfo].
+const AppName = "app"
+
+// Result is the result of [Run].
+type Result[T any] struct {
+	// CLI is the parsed CLI. If the CLI exited while parsing (e.g. when using
+	// --help), [clix.CLI.Context] is still set, as long as the arguments could be
+	// traced.
+	CLI *clix.CLI[T]
+
+	// Exited is true if the CLI exited while parsing, e.g. due to --help, a
+	// command such as "generate-markdown", or an error.
+	Exited bool
+
+	// ExitCode is the exit code, if [Result.Exited] is true.
+	ExitCode int
+
+	// Stdout is everything written to stdout by kong and clix while parsing.
+	Stdout string
+
+	// Stderr is everything written to stderr by kong and clix while parsing.
+	Stderr string
+
+	// Logs are the log records emitted while parsing, through both the CLI's
+	// logger and the global logger. See [LogHandler].
+	Logs *LogHandler
+}
+
+// exit is used to unwind the stack when kong exits, so the CLI stops parsing,
+// like it would when the process exits.
+type exit struct {
+	code int
+}
+
+// Option configures [Run]. See [WithOptions], [WithEnv] and [WithFile].
+type Option[T any] func(o *runOptions[T])
+
+type runOptions[T any] struct {
+	cli   []clix.Option[T]
+	setup []func(t testing.TB)
+}
+
+// WithOptions adds options used when creating the [clix.CLI], e.g.
+// [clix.Defaults].
+func WithOptions[T any](opts ...clix.Option[T]) Option[T] {
+	return func(o *runOptions[T]) {
+		o.cli = append(o.cli, opts...)
+	}
+}
+
+// WithEnv sets an environment variable for the duration of [Run], after the
+// environment is cleared.
+func WithEnv[T any](key, value string) Option[T] {
+	return func(o *runOptions[T]) {
+		o.setup = append(o.setup, func(t testing.TB) {
+			t.Helper()
+			if err := os.Setenv(key, value); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// WithFile writes a file (e.g. ".env") relative to the temporary working
+// directory used by [Run], creating parent directories as needed.
+func WithFile[T any](name, content string) Option[T] {
+	return func(o *runOptions[T]) {
+		o.setup = append(o.setup, func(t testing.TB) {
+			t.Helper()
+			if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+				t.Fatalf("clixtest: failed to create directory for %q: %v", name, err)
+			}
+			if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+				t.Fatalf("clixtest: failed to write %q: %v", name, err)
+			}
+		})
+	}
+}
+
+// Run parses args (excluding the application name) with a new [clix.CLI],
+// using the provided options, in-process. Log records, and output written
+// through kong's stdout/stderr writers (which clix uses for help, rendering,
+// logging, progress and notices) are captured, and exits are intercepted,
+// stopping parsing, and returned in the [Result]. Output written directly to
+// os.Stdout or os.Stderr is not captured.
+//
+// For the duration of the call, the environment is cleared, and the working
+// directory is changed to a new temporary directory, so ".env" loading (see
+// [clix.WithEnvFiles]) is hermetic. Use [WithEnv] and [WithFile] to populate
+// them. Both are restored before returning, along with os.Args and the global
+// logger. As this is process-wide state, Run panics when used in parallel
+// tests (see [testing.T.Setenv]).
+func Run[T any](t testing.TB, args []string, opts ...Option[T]) (result *Result[T]) {
+	t.Helper()
+
+	// Setting an environment variable through the test panics if the test (or
+	// any parent) is parallel.
+	t.Setenv(UpdateEnv, os.Getenv(UpdateEnv))
+
+	var o runOptions[T]
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	result = &Result[T]{Logs: &LogHandler{}}
+
+	defer isolate(t)()
+	os.Args = append([]string{AppName}, args...)
+
+	for _, setup := range o.setup {
+		setup(t)
+	}
+
+	oldLogger := slog.Default()
+	defer slog.SetDefault(oldLogger)
+	slog.SetDefault(slog.New(result.Logs))
+
+	var stdout, stderr bytes.Buffer
+	var kctx *kong.Context
+
+	options := []clix.Option[T]{
+		func(cli *clix.CLI[T]) { result.CLI = cli },
+		clix.WithLogHandler[T](result.Logs),
+		clix.WithKongOptions[T](
+			kong.Writers(&stdout, &stderr),
+			kong.Exit(func(code int) { panic(exit{code: code}) }),
+			kong.WithBeforeReset(func(ctx *kong.Context) error {
+				kctx = ctx
+				return nil
+			}),
+		),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(exit)
+			if !ok {
+				panic(r)
+			}
+
+			result.Exited = true
+			result.ExitCode = e.code
+			if result.CLI.Context == nil {
+				result.CLI.Context = kctx
+			}
+		}
+
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+	}()
+
+	clix.New(append(options, o.cli...)...)
+	return result
+}
+
+// isolate clears the environment, and changes to a new temporary working
+// directory, returning a function which restores them (and os.Args).
+func isolate(t testing.TB) (restore func()) {
+	t.Helper()
+
+	env := os.Environ()
+	args := os.Args
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Clearenv()
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		os.Clearenv()
+		for _, kv := range env {
+			k, v, _ := strings.Cut(kv, "=")
+			_ = os.Setenv(k, v)
+		}
+
+		os.Args = args
+
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clixtest_test
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix/v2"
+	"github.com/lrstanley/clix/v2/clixtest"
+)
+
+type testFlags struct {
+	Name     string `name:"name" env:"TEST_NAME" default:"world" help:"name to greet"`
+	Greeting string `name:"greeting" env:"TEST_GREETING" alias_env:"TEST_OLD_GREETING" default:"hello" help:"greeting to use"`
+
+	Greet struct {
+		Loud bool `name:"loud" help:"greet loudly"`
+	} `cmd:"" help:"greet someone"`
+}
+
+func testOptions(opts ...clixtest.Option[testFlags]) []clixtest.Option[testFlags] {
+	return append([]clixtest.Option[testFlags]{
+		clixtest.WithOptions(
+			clix.WithAppInfo[testFlags](clix.AppInfo{Name: "greeter", Version: "v1.2.3"}),
+			clix.WithEnvFiles[testFlags](),
+			clix.WithMarkdownPlugin[testFlags](),
+			clix.WithDeprecationPlugin[testFlags](),
+		),
+	}, opts...)
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("TEST_NAME", "outside")
+
+	result := clixtest.Run(t, []string{"greet", "--loud"}, testOptions(
+		clixtest.WithFile[testFlags](".env", "TEST_NAME=dotenv\n"),
+		clixtest.WithEnv[testFlags]("TEST_OLD_GREETING", "hi"),
+	)...)
+
+	if result.Exited {
+		t.Fatalf("expected no exit, got exit code %d:\n%s", result.ExitCode, result.Stderr)
+	}
+
+	if result.CLI.Context.Command() != "greet" || !result.CLI.Flags.Greet.Loud {
+		t.Fatalf("unexpected command %q or flags %+v", result.CLI.Context.Command(), result.CLI.Flags)
+	}
+
+	if result.CLI.Flags.Name != "dotenv" {
+		t.Fatalf("expected name from .env (not the outer environment), got %q", result.CLI.Flags.Name)
+	}
+
+	if result.CLI.Flags.Greeting != "hi" {
+		t.Fatalf("expected greeting from alias_env, got %q", result.CLI.Flags.Greeting)
+	}
+
+	warnings := result.Logs.Messages(slog.LevelWarn)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "TEST_OLD_GREETING") {
+		t.Fatalf("expected deprecation warning to be captured, got %q", warnings)
+	}
+
+	if v := os.Getenv("TEST_NAME"); v != "outside" {
+		t.Fatalf("expected environment to be restored, got TEST_NAME=%q", v)
+	}
+	if _, ok := os.LookupEnv("TEST_OLD_GREETING"); ok {
+		t.Fatal("expected environment set by the CLI to be removed")
+	}
+	if _, err := os.Stat(".env"); err == nil {
+		t.Fatal("expected .env to be written to a temporary directory")
+	}
+}
+
+func TestRunExit(t *testing.T) {
+	result := clixtest.Run(t, []string{"greet", "--invalid"}, testOptions()...)
+
+	if !result.Exited || result.ExitCode == 0 {
+		t.Fatalf("expected non-zero exit, got exit code %d (exited: %t)", result.ExitCode, result.Exited)
+	}
+
+	if !strings.Contains(result.Stderr, "unknown flag --invalid") {
+		t.Fatalf("expected error in stderr, got:\n%s", result.Stderr)
+	}
+
+	// Parsing stops on exit, so usage should only be printed once.
+	if strings.Count(result.Stdout, "Usage:") != 1 {
+		t.Fatalf("expected usage to be printed once, got:\n%s", result.Stdout)
+	}
+}
+
+func TestGoldenHelp(t *testing.T) {
+	clixtest.GoldenHelp(t, "testdata/help.golden", nil, testOptions()...)
+
+	result := clixtest.GoldenHelp(t, "testdata/help-greet.golden", []string{"greet"}, testOptions()...)
+	if result.CLI.Context == nil || result.CLI.Context.Command() != "greet" {
+		t.Fatal("expected context to be set when exiting")
+	}
+}
+
+func TestGoldenMarkdown(t *testing.T) {
+	result := clixtest.GoldenMarkdown(t, "testdata/markdown.golden", testOptions()...)
+
+	if !slices.Contains(strings.Split(result.Stdout, "\n"), "## Deprecated") {
+		t.Fatalf("expected deprecated section in markdown, got:\n%s", result.Stdout)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected Run to panic in a parallel test")
+			}
+		}()
+
+		clixtest.Run(t, []string{"greet"}, testOptions()...)
+	})
+}
+
+func TestLogHandlerGroups(t *testing.T) {
+	h := &clixtest.LogHandler{}
+	slog.New(h).WithGroup("a").With("x", 1).WithGroup("b").With("y", 2).WithGroup("empty").Info("test", "z", 3)
+
+	records := h.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	var got []string
+	var walk func(prefix string, attrs []slog.Attr)
+	walk = func(prefix string, attrs []slog.Attr) {
+		for _, a := range attrs {
+			if a.Value.Kind() == slog.KindGroup {
+				walk(prefix+a.Key+".", a.Value.Group())
+				continue
+			}
+			got = append(got, prefix+a.Key+"="+a.Value.String())
+		}
+	}
+
+	var attrs []slog.Attr
+	records[0].Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	if len(attrs) != 1 || attrs[0].Key != "a" {
+		t.Fatalf("expected a single top-level group, got %v", attrs)
+	}
+	walk("", attrs)
+
+	want := []string{"a.x=1", "a.b.y=2", "a.b.empty.z=3"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected attributes %q, got %q", want, got)
+	}
+}
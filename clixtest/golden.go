@@ -0,0 +1,98 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clixtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/lrstanley/clix/v2"
+)
+
+// UpdateEnv is the environment variable which, when set to a true value, makes
+// [Golden] (and the helpers using it) update golden files rather than comparing
+// against them, e.g.:
+//
+//	CLIXTEST_UPDATE=1 go test ./...
+const UpdateEnv = "CLIXTEST_UPDATE"
+
+// Golden compares got against the contents of the golden file at path
+// (relative paths are relative to the package being tested), failing the test
+// if they differ. If [UpdateEnv] is set, the golden file is written instead.
+func Golden(t testing.TB, path, got string) {
+	t.Helper()
+
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateEnv)); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("golden file %q not found, create it with %s=1", path, UpdateEnv)
+		}
+		t.Fatal(err)
+	}
+
+	if string(want) != got {
+		t.Errorf(
+			"output doesn't match golden file %q (update it with %s=1):\n--- want:\n%s\n--- got:\n%s",
+			path, UpdateEnv, want, got,
+		)
+	}
+}
+
+// GoldenHelp runs the CLI with args and "--help" (see [Run]), comparing the help
+// output against the golden file at path (see [Golden]). Build-specific version
+// information included in the help output (Go version, OS and architecture) is
+// replaced with fixed values, so golden files are portable.
+func GoldenHelp[T any](t testing.TB, path string, args []string, opts ...Option[T]) *Result[T] {
+	t.Helper()
+
+	result := Run(t, append(slices.Clone(args), "--help"), append(slices.Clone(opts), stableVersion[T]())...)
+	if !result.Exited || result.ExitCode != 0 {
+		t.Fatalf("expected --help to exit with code 0, got %d (exited: %t):\n%s", result.ExitCode, result.Exited, result.Stderr)
+	}
+
+	Golden(t, path, result.Stdout)
+	return result
+}
+
+// GoldenMarkdown runs the "generate-markdown" command (see [Run] and
+// [clix.WithMarkdownPlugin], which must be included in opts, e.g. via
+// [WithOptions] and [clix.Defaults]), comparing the generated markdown against the golden file at
+// path (see [Golden]). Like [GoldenHelp], build-specific version information is
+// replaced with fixed values.
+func GoldenMarkdown[T any](t testing.TB, path string, opts ...Option[T]) *Result[T] {
+	t.Helper()
+
+	result := Run(t, []string{"generate-markdown"}, append(slices.Clone(opts), stableVersion[T]())...)
+	if !result.Exited || result.ExitCode != 0 {
+		t.Fatalf("expected generate-markdown to exit with code 0, got %d (exited: %t):\n%s", result.ExitCode, result.Exited, result.Stderr)
+	}
+
+	Golden(t, path, result.Stdout)
+	return result
+}
+
+// stableVersion replaces build-specific version information with fixed values.
+func stableVersion[T any]() Option[T] {
+	return WithOptions(func(cli *clix.CLI[T]) {
+		v := cli.GetVersion()
+		v.GoVersion = "go"
+		v.OS = "os"
+		v.Arch = "arch"
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clixtest
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// LogHandler is an in-memory [log/slog.Handler], which records all log records
+// (regardless of level). It is safe for concurrent use. Attributes and groups
+// added via [slog.Logger.With] and [slog.Logger.WithGroup] are added to the
+// records.
+type LogHandler struct {
+	root *LogHandler
+	goas []groupOrAttrs // Groups and attributes, in the order they were added.
+
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+// groupOrAttrs is either a group (if group is non-empty), or attributes added
+// at the current group depth.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+var _ slog.Handler = (*LogHandler)(nil)
+
+func (h *LogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *LogHandler) Handle(_ context.Context, r slog.Record) error {
+	r = r.Clone()
+
+	if len(h.goas) > 0 {
+		var attrs []slog.Attr
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+
+		// Work outwards from the innermost group, prepending the handler
+		// attributes at each depth, and nesting everything so far within
+		// each group. Empty groups are omitted, like the builtin handlers.
+		for i := len(h.goas) - 1; i >= 0; i-- {
+			goa := h.goas[i]
+			if goa.group == "" {
+				attrs = append(slices.Clone(goa.attrs), attrs...)
+				continue
+			}
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			}
+		}
+
+		r = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		r.AddAttrs(attrs...)
+	}
+
+	root := h.rootHandler()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.records = append(root.records, r)
+	return nil
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: slices.Clone(attrs)})
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *LogHandler) with(goa groupOrAttrs) *LogHandler {
+	return &LogHandler{
+		root: h.rootHandler(),
+		goas: append(slices.Clone(h.goas), goa),
+	}
+}
+
+func (h *LogHandler) rootHandler() *LogHandler {
+	if h.root != nil {
+		return h.root
+	}
+	return h
+}
+
+// Records returns a copy of all recorded log records.
+func (h *LogHandler) Records() []slog.Record {
+	root := h.rootHandler()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	return slices.Clone(root.records)
+}
+
+// Messages returns the messages of all recorded log records at or above the
+// provided level.
+func (h *LogHandler) Messages(level slog.Level) []string {
+	var messages []string
+	for _, r := range h.Records() {
+		if r.Level >= level {
+			messages = append(messages, r.Message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,374 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+)
+
+// ExitCodeIncompatible is the exit code used by the "check-compat" command when
+// breaking changes are found. See [WithCompatCheckPlugin].
+const ExitCodeIncompatible = 5
+
+// DefaultCompatSnapshot is the default path of the snapshot used by the
+// "check-compat" command. See [WithCompatCheckPlugin].
+const DefaultCompatSnapshot = "cli.json"
+
+// Kinds of breaking changes reported by [CheckCompatibility].
+const (
+	CompatCommandRemoved   = "command-removed"
+	CompatArgRemoved       = "arg-removed"
+	CompatArgRequired      = "arg-required"
+	CompatFlagRemoved      = "flag-removed"
+	CompatFlagRequired     = "flag-required"
+	CompatFlagTypeChanged  = "flag-type-changed"
+	CompatShortRemoved     = "short-removed"
+	CompatNegationRemoved  = "negation-removed"
+	CompatEnvRemoved       = "env-removed"
+	CompatEnumValueRemoved = "enum-value-removed"
+)
+
+// CompatIssue is a breaking change between two versions of the CLI surface. See
+// [CheckCompatibility].
+type CompatIssue struct {
+	Kind    string `json:"kind"`
+	Command string `json:"command,omitempty"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func (i CompatIssue) String() string {
+	if i.Command == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s (command %q)", i.Message, i.Command)
+}
+
+// CheckCompatibility compares the current CLI surface against a previous one (e.g.
+// a [CLISpec] snapshot committed with the last release), returning any breaking
+// changes: removed commands, arguments, flags (or their short names, aliases and
+// negations), environment variables and enum values, as well as flags and
+// arguments which were optional and are now required, and flags which changed
+// type. Flags are resolved including those inherited from parent commands, so
+// moving a flag to a parent command isn't reported. Additions are not reported.
+// Deprecated flags, commands and environment variables (see
+// [WithDeprecationPlugin]) still work, so they aren't reported until removed.
+func CheckCompatibility(previous, current *CLISpec) []CompatIssue {
+	return compareCommand(
+		"",
+		CommandSpec{Flags: previous.Flags, Args: previous.Args, Commands: previous.Commands},
+		CommandSpec{Flags: current.Flags, Args: current.Args, Commands: current.Commands},
+		nil,
+		nil,
+	)
+}
+
+// compareCommand compares two versions of a command. prevInherited and
+// curInherited are the flags of the parent commands.
+func compareCommand(path string, prev, cur CommandSpec, prevInherited, curInherited []FlagSpec) (issues []CompatIssue) {
+	issues = append(issues, compareFlags(
+		path,
+		prev.Flags,
+		cur.Flags,
+		slices.Concat(prevInherited, prev.Flags),
+		slices.Concat(curInherited, cur.Flags),
+	)...)
+	issues = append(issues, compareArgs(path, prev.Args, cur.Args)...)
+
+	for _, pc := range prev.Commands {
+		idx := slices.IndexFunc(cur.Commands, func(c CommandSpec) bool {
+			return c.Name == pc.Name || slices.Contains(c.Aliases, pc.Name)
+		})
+		if idx == -1 {
+			issues = append(issues, CompatIssue{
+				Kind:    CompatCommandRemoved,
+				Command: path,
+				Name:    pc.Name,
+				Message: fmt.Sprintf("command %q was removed", pc.Path),
+			})
+			continue
+		}
+
+		cc := cur.Commands[idx]
+
+		for _, alias := range pc.Aliases {
+			if alias != cc.Name && !slices.Contains(cc.Aliases, alias) {
+				issues = append(issues, CompatIssue{
+					Kind:    CompatCommandRemoved,
+					Command: path,
+					Name:    alias,
+					Message: fmt.Sprintf("command alias %q (of %q) was removed", alias, pc.Path),
+				})
+			}
+		}
+
+		issues = append(issues, compareCommand(
+			cc.Path,
+			pc,
+			cc,
+			slices.Concat(prevInherited, prev.Flags),
+			slices.Concat(curInherited, cur.Flags),
+		)...)
+	}
+
+	return issues
+}
+
+// compareFlags compares the flags of a command. prevAll and curAll also include
+// the flags inherited from parent commands, which are used to look up flags
+// which moved between a command and its parents.
+func compareFlags(path string, prev, cur, prevAll, curAll []FlagSpec) (issues []CompatIssue) {
+	issue := func(kind, name, format string, args ...any) {
+		issues = append(issues, CompatIssue{
+			Kind:    kind,
+			Command: path,
+			Name:    name,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, cf := range cur {
+		if cf.Required && !slices.ContainsFunc(prevAll, func(f FlagSpec) bool { return f.Name == cf.Name }) {
+			issue(CompatFlagRequired, cf.Name, "required flag --%s was added", cf.Name)
+		}
+	}
+
+	for _, pf := range prev {
+		idx := slices.IndexFunc(curAll, func(f FlagSpec) bool {
+			return f.Name == pf.Name || slices.Contains(f.Aliases, pf.Name)
+		})
+		if idx == -1 {
+			issue(CompatFlagRemoved, pf.Name, "flag --%s was removed", pf.Name)
+			continue
+		}
+
+		cf := curAll[idx]
+
+		if pf.Type != "" && cf.Type != "" && pf.Type != cf.Type {
+			issue(CompatFlagTypeChanged, pf.Name, "flag --%s changed type from %s to %s", pf.Name, pf.Type, cf.Type)
+		}
+
+		for _, alias := range pf.Aliases {
+			if alias != cf.Name && !slices.Contains(cf.Aliases, alias) {
+				issue(CompatFlagRemoved, alias, "flag alias --%s (of --%s) was removed", alias, pf.Name)
+			}
+		}
+
+		if pf.Short != "" && pf.Short != cf.Short {
+			issue(CompatShortRemoved, pf.Name, "short flag -%s (of --%s) was removed", pf.Short, pf.Name)
+		}
+
+		if pf.Negation != "" && pf.Negation != cf.Negation {
+			issue(CompatNegationRemoved, pf.Name, "negated flag --%s (of --%s) was removed", pf.Negation, pf.Name)
+		}
+
+		if !pf.Required && cf.Required {
+			issue(CompatFlagRequired, pf.Name, "flag --%s was optional, and is now required", pf.Name)
+		}
+
+		for _, env := range pf.Envs {
+			if !slices.Contains(cf.Envs, env) && !slices.Contains(cf.DeprecatedEnvs, env) {
+				issue(CompatEnvRemoved, pf.Name, "environment variable %s (of --%s) was removed or renamed", env, pf.Name)
+			}
+		}
+
+		// If there were previously no enum values, any value was allowed, so
+		// restricting it is also a breaking change, however, that can't be
+		// described as a removed value.
+		if len(cf.Enum) > 0 {
+			for _, value := range pf.Enum {
+				if !slices.Contains(cf.Enum, value) {
+					issue(CompatEnumValueRemoved, pf.Name, "value %q of flag --%s was removed", value, pf.Name)
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+func compareArgs(path string, prev, cur []ArgSpec) (issues []CompatIssue) {
+	for i, pa := range prev {
+		if i >= len(cur) {
+			issues = append(issues, CompatIssue{
+				Kind:    CompatArgRemoved,
+				Command: path,
+				Name:    pa.Name,
+				Message: fmt.Sprintf("argument <%s> was removed", pa.Name),
+			})
+			continue
+		}
+
+		if !pa.Required && cur[i].Required {
+			issues = append(issues, CompatIssue{
+				Kind:    CompatArgRequired,
+				Command: path,
+				Name:    cur[i].Name,
+				Message: fmt.Sprintf("argument <%s> was optional, and is now required", cur[i].Name),
+			})
+		}
+
+		if len(cur[i].Enum) > 0 {
+			for _, value := range pa.Enum {
+				if !slices.Contains(cur[i].Enum, value) {
+					issues = append(issues, CompatIssue{
+						Kind:    CompatEnumValueRemoved,
+						Command: path,
+						Name:    cur[i].Name,
+						Message: fmt.Sprintf("value %q of argument <%s> was removed", value, cur[i].Name),
+					})
+				}
+			}
+		}
+	}
+
+	for _, ca := range cur[min(len(prev), len(cur)):] {
+		if ca.Required {
+			issues = append(issues, CompatIssue{
+				Kind:    CompatArgRequired,
+				Command: path,
+				Name:    ca.Name,
+				Message: fmt.Sprintf("required argument <%s> was added", ca.Name),
+			})
+		}
+	}
+
+	return issues
+}
+
+// ReadCLISpec reads a JSON encoded [CLISpec], e.g. as generated by
+// "generate-docs --format=json".
+func ReadCLISpec(path string) (*CLISpec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &CLISpec{}
+	if err = json.Unmarshal(b, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse CLI spec %q: %w", path, err)
+	}
+	return spec, nil
+}
+
+// WithCompatCheckPlugin adds a hidden "check-compat" command to the CLI, which
+// compares the current CLI surface (see [CLISpec]) against a previously committed
+// snapshot (defaults to [DefaultCompatSnapshot] if snapshot is empty), printing
+// any breaking changes (see [CheckCompatibility]), and exiting with
+// [ExitCodeIncompatible] if any are found. Intended to be ran in CI, with
+// "check-compat --update" (or "generate-docs --format=json") used to update the
+// snapshot when releasing. Like the markdown plugin, it's invoked before kong
+// applies additional restrictions, so it ignores any other required flags.
+func WithCompatCheckPlugin[T any](snapshot string) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Swap(true) {
+			return
+		}
+
+		if snapshot == "" {
+			snapshot = DefaultCompatSnapshot
+		}
+
+		cli.kongOptions = append(
+			cli.kongOptions, kong.DynamicCommand(
+				"check-compat",
+				"check the CLI for breaking changes against a snapshot",
+				"",
+				&CompatCommand{snapshot: snapshot},
+				"hidden",
+			),
+		)
+	}
+}
+
+// CompatCommand is the command added by [WithCompatCheckPlugin].
+type CompatCommand struct {
+	Snapshot string `name:"snapshot" env:"CLIX_COMPAT_SNAPSHOT" placeholder:"PATH" help:"path to the CLI snapshot to compare against"`
+	Update   bool   `name:"update" help:"write the current CLI surface to the snapshot, rather than comparing"`
+	JSON     bool   `name:"json" help:"output results in JSON format"`
+
+	snapshot string
+}
+
+func (c *CompatCommand) BeforeReset(kctx *kong.Context, path *kong.Path, version *Version) error {
+	node := path.Command
+
+	c.Snapshot = earlyFlagValue(kctx, node, "snapshot")
+	if c.Snapshot == "" {
+		c.Snapshot = c.snapshot
+	}
+	c.Update, _ = strconv.ParseBool(earlyFlagValue(kctx, node, "update"))
+	c.JSON, _ = strconv.ParseBool(earlyFlagValue(kctx, node, "json"))
+
+	if c.Update {
+		out, err := (&MarkdownCommand{}).GenerateJSON(kctx.Model, version)
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(c.Snapshot, []byte(out), 0o600); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(kctx.Stdout, "wrote CLI snapshot to %s\n", c.Snapshot)
+		kctx.Exit(0)
+		return nil
+	}
+
+	previous, err := ReadCLISpec(c.Snapshot)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("snapshot %q not found, create it with --update: %w", c.Snapshot, err)
+		}
+		return err
+	}
+
+	issues := CheckCompatibility(previous, NewCLISpec(kctx.Model, version))
+
+	if err = writeCompatIssues(kctx.Stdout, c.Snapshot, issues, c.JSON); err != nil {
+		return err
+	}
+
+	if len(issues) > 0 {
+		kctx.Exit(ExitCodeIncompatible)
+		return nil
+	}
+
+	kctx.Exit(0)
+	return nil
+}
+
+func writeCompatIssues(w io.Writer, snapshot string, issues []CompatIssue, asJSON bool) error {
+	if asJSON {
+		if issues == nil {
+			issues = []CompatIssue{}
+		}
+		return renderJSON(w, issues)
+	}
+
+	for _, issue := range issues {
+		if _, err := fmt.Fprintf(w, "BREAKING  %-18s %s\n", issue.Kind, issue); err != nil {
+			return err
+		}
+	}
+
+	if len(issues) == 0 {
+		_, err := fmt.Fprintf(w, "no breaking changes found against %s\n", snapshot)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d breaking change(s) found against %s\n", len(issues), snapshot)
+	return err
+}
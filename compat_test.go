@@ -0,0 +1,190 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	previous := &CLISpec{
+		Flags: []FlagSpec{
+			{Name: "debug", Short: "D", Envs: []string{"DEBUG"}},
+			{Name: "old", Envs: []string{"OLD"}},
+		},
+		Commands: []CommandSpec{
+			{
+				Name:  "serve",
+				Path:  "serve",
+				Flags: []FlagSpec{{Name: "format", Enum: []string{"json", "text", "yaml"}, Envs: []string{"FORMAT"}}},
+				Args:  []ArgSpec{{Name: "addr"}},
+			},
+			{Name: "remove", Path: "remove", Aliases: []string{"rm"}},
+		},
+	}
+
+	current := &CLISpec{
+		Flags: []FlagSpec{
+			{Name: "debug", Envs: []string{"DEBUG"}},
+			{Name: "new", Aliases: []string{"old"}, Envs: []string{"OLD", "NEW"}},
+			{Name: "token", Required: true},
+		},
+		Commands: []CommandSpec{
+			{
+				Name:  "serve",
+				Path:  "serve",
+				Flags: []FlagSpec{{Name: "format", Required: true, Enum: []string{"json", "text"}, Envs: []string{"SERVE_FORMAT"}}},
+				Args:  []ArgSpec{{Name: "addr", Required: true}},
+			},
+			{Name: "delete", Path: "delete", Aliases: []string{"remove"}},
+		},
+	}
+
+	var got []string
+	for _, issue := range CheckCompatibility(previous, current) {
+		got = append(got, issue.Kind+":"+issue.Command+":"+issue.Name)
+	}
+
+	want := []string{
+		CompatFlagRequired + "::token",
+		CompatShortRemoved + "::debug",
+		CompatFlagRequired + ":serve:format",
+		CompatEnvRemoved + ":serve:format",
+		CompatEnumValueRemoved + ":serve:format",
+		CompatArgRequired + ":serve:addr",
+		CompatCommandRemoved + "::rm",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected issues:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	if issues := CheckCompatibility(previous, previous); len(issues) != 0 {
+		t.Fatalf("expected no issues when comparing against itself, got %v", issues)
+	}
+}
+
+func TestCheckCompatibilityInheritedFlags(t *testing.T) {
+	previous := &CLISpec{
+		Commands: []CommandSpec{{
+			Name:  "serve",
+			Path:  "serve",
+			Flags: []FlagSpec{{Name: "port", Type: "int"}, {Name: "host", Type: "string"}},
+		}},
+	}
+
+	// Moving a flag to the parent command still works.
+	current := &CLISpec{
+		Flags: []FlagSpec{{Name: "port", Type: "int"}},
+		Commands: []CommandSpec{{
+			Name:  "serve",
+			Path:  "serve",
+			Flags: []FlagSpec{{Name: "host", Type: "string"}},
+		}},
+	}
+	if issues := CheckCompatibility(previous, current); len(issues) != 0 {
+		t.Fatalf("expected no issues when moving a flag to a parent, got %v", issues)
+	}
+
+	current.Flags[0].Type = "string"
+	issues := CheckCompatibility(previous, current)
+	if len(issues) != 1 || issues[0].Kind != CompatFlagTypeChanged || issues[0].Command != "serve" {
+		t.Fatalf("expected type change issue, got %v", issues)
+	}
+
+	// Moving a flag from the parent command to a child removes it from others.
+	if issues = CheckCompatibility(current, previous); len(issues) != 1 || issues[0].Kind != CompatFlagRemoved {
+		t.Fatalf("expected flag removed issue, got %v", issues)
+	}
+}
+
+func TestCheckCompatibilityDeprecatedEnvs(t *testing.T) {
+	previous := &CLISpec{Flags: []FlagSpec{{Name: "token", Envs: []string{"TOKEN"}}}}
+
+	// Renamed, but the old environment variable still works.
+	current := &CLISpec{Flags: []FlagSpec{{Name: "token", Envs: []string{"APP_TOKEN"}, DeprecatedEnvs: []string{"TOKEN"}}}}
+	if issues := CheckCompatibility(previous, current); len(issues) != 0 {
+		t.Fatalf("expected no issues for deprecated env, got %v", issues)
+	}
+
+	current = &CLISpec{Flags: []FlagSpec{{Name: "token", Envs: []string{"APP_TOKEN"}}}}
+	issues := CheckCompatibility(previous, current)
+	if len(issues) != 1 || issues[0].Kind != CompatEnvRemoved {
+		t.Fatalf("expected env removed issue, got %v", issues)
+	}
+}
+
+func runCompatCommand[T any](t *testing.T, args ...string) (out string, code int) {
+	t.Helper()
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = append([]string{"testapp", "check-compat"}, args...)
+
+	var buf bytes.Buffer
+	code = -1
+
+	New(
+		WithKongOptions[T](
+			kong.Writers(&buf, &bytes.Buffer{}),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithCompatCheckPlugin[T](""),
+	)
+
+	return buf.String(), code
+}
+
+func TestCompatCommand(t *testing.T) {
+	type V1 struct {
+		Output string `name:"output" env:"OUTPUT" enum:"json,text" default:"text"`
+		Name   string `name:"name" required:""`
+	}
+
+	type V2 struct {
+		Output string `name:"output" env:"APP_OUTPUT" enum:"json" default:"json"`
+		Name   string `name:"name" required:""`
+		Token  string `name:"token" required:""`
+	}
+
+	snapshot := filepath.Join(t.TempDir(), "cli.json")
+
+	if _, code := runCompatCommand[V1](t, "--snapshot", snapshot, "--update"); code != 0 {
+		t.Fatalf("expected exit code 0 when updating snapshot, got %d", code)
+	}
+
+	if out, code := runCompatCommand[V1](t, "--snapshot", snapshot); code != 0 {
+		t.Fatalf("expected exit code 0 for unchanged CLI, got %d:\n%s", code, out)
+	}
+
+	t.Setenv("CLIX_COMPAT_SNAPSHOT", snapshot)
+
+	out, code := runCompatCommand[V2](t, "--json")
+	if code != ExitCodeIncompatible {
+		t.Fatalf("expected exit code %d, got %d:\n%s", ExitCodeIncompatible, code, out)
+	}
+
+	var issues []CompatIssue
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&issues); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %d: %v", len(issues), issues)
+	}
+}
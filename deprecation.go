@@ -0,0 +1,336 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+)
+
+// Kinds of deprecations. See [Deprecation].
+const (
+	DeprecatedFlag    = "flag"
+	DeprecatedEnv     = "env"
+	DeprecatedCommand = "command"
+)
+
+// Deprecation describes a deprecated flag, environment variable or command. See
+// [WithDeprecationPlugin] for the supported struct tags.
+type Deprecation struct {
+	// Kind is one of [DeprecatedFlag], [DeprecatedEnv] or [DeprecatedCommand].
+	Kind string `json:"kind"`
+
+	// Name is the deprecated name, e.g. "--old-name", "OLD_NAME" or "old-name".
+	Name string `json:"name"`
+
+	// Command is the path of the command the flag or environment variable belongs
+	// to (empty for global flags), or the path of the deprecated command.
+	Command string `json:"command,omitempty"`
+
+	// Message describes the replacement, e.g. "use --new-name".
+	Message string `json:"message,omitempty"`
+
+	// RemovedIn is the version in which the deprecated name stops working.
+	RemovedIn string `json:"removed_in,omitempty"`
+
+	node *kong.Node
+	flag *kong.Flag
+}
+
+func (d Deprecation) String() string {
+	s := d.subject() + " is deprecated"
+	if d.RemovedIn != "" {
+		s += " and will be removed in " + d.RemovedIn
+	}
+	if d.Message != "" {
+		s += ": " + d.Message
+	}
+	return s
+}
+
+// removedError returns the error used when the deprecation is used, and the
+// application version is at least [Deprecation.RemovedIn].
+func (d Deprecation) removedError() error {
+	s := d.subject() + " was removed in " + d.RemovedIn
+	if d.Message != "" {
+		s += ": " + d.Message
+	}
+	return errors.New(s)
+}
+
+// subject describes what is deprecated, e.g. `flag --old-name (command "foo")`.
+func (d Deprecation) subject() string {
+	s := d.Kind + " " + d.Name
+	if d.Kind == DeprecatedEnv && d.flag != nil {
+		s = fmt.Sprintf("environment variable %s (of --%s)", d.Name, d.flag.Name)
+	}
+	if d.Command != "" && d.Kind != DeprecatedCommand {
+		s += fmt.Sprintf(" (command %q)", d.Command)
+	}
+	return s
+}
+
+// Deprecations returns all deprecations of the node and its children, in the
+// order in which they're defined. See [WithDeprecationPlugin].
+func Deprecations(node *kong.Node) []Deprecation {
+	var results []Deprecation
+
+	if node.Type == kong.CommandNode && isDeprecated(node.Tag) {
+		results = append(results, Deprecation{
+			Kind:      DeprecatedCommand,
+			Name:      commandPath(node),
+			Command:   commandPath(node),
+			Message:   node.Tag.Get("deprecated"),
+			RemovedIn: node.Tag.Get("removed_in"),
+			node:      node,
+		})
+	}
+
+	for _, flag := range node.Flags {
+		if isDeprecated(flag.Tag) {
+			results = append(results, Deprecation{
+				Kind:      DeprecatedFlag,
+				Name:      "--" + flag.Name,
+				Command:   commandPath(node),
+				Message:   flag.Tag.Get("deprecated"),
+				RemovedIn: flag.Tag.Get("removed_in"),
+				node:      node,
+				flag:      flag,
+			})
+		}
+
+		for _, env := range aliasEnvs(flag) {
+			message := "use --" + flag.Name
+			if len(flag.Tag.Envs) > 0 {
+				message = "use " + flag.Tag.Envs[0]
+			}
+
+			results = append(results, Deprecation{
+				Kind:      DeprecatedEnv,
+				Name:      env,
+				Command:   commandPath(node),
+				Message:   message,
+				RemovedIn: flag.Tag.Get("removed_in"),
+				node:      node,
+				flag:      flag,
+			})
+		}
+	}
+
+	for _, child := range node.Children {
+		results = append(results, Deprecations(child)...)
+	}
+
+	return results
+}
+
+// aliasEnvs returns the deprecated environment variables of the flag, from the
+// "alias_env" struct tag.
+func aliasEnvs(flag *kong.Flag) []string {
+	if flag.Tag == nil {
+		return nil
+	}
+
+	var envs []string
+	for env := range strings.SplitSeq(flag.Tag.Get("alias_env"), ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// isDeprecated returns true if the struct tag marks a flag or command as
+// deprecated.
+func isDeprecated(tag *kong.Tag) bool {
+	return tag != nil && tag.Has("deprecated")
+}
+
+// WithStrictDeprecations turns usage of deprecated flags, environment variables
+// and commands into errors, rather than warnings. Useful in CI, to make sure
+// scripts are migrated before the deprecated names are removed. See
+// [WithDeprecationPlugin].
+func WithStrictDeprecations[T any]() Option[T] {
+	return func(cli *CLI[T]) {
+		cli.strictDeprecations = true
+	}
+}
+
+// WithDeprecationPlugin adds support for renaming flags, environment variables
+// and commands without breaking existing users, using the following struct tags:
+//
+//   - deprecated:"use --new-name": marks a flag or command as deprecated. It keeps
+//     working, but is hidden from help output, and listed in the "Deprecated"
+//     section of the generated markdown instead.
+//   - alias_env:"OLD_NAME,OTHER_NAME": deprecated environment variables for a
+//     flag, used when none of the flag's "env" variables are set.
+//   - removed_in:"v2.0.0": the version (see [AppInfo.Version]) in which the
+//     deprecated flag, command or "alias_env" variables stop working. Once the
+//     application version is at least this version, using them is an error.
+//
+// Using deprecated names logs a warning (once per name) with the replacement, or
+// returns an error when [WithStrictDeprecations] is used, e.g.:
+//
+//	type Flags struct {
+//		Token    string `name:"token" env:"APP_TOKEN" alias_env:"TOKEN" removed_in:"v2.0.0"`
+//		OldToken string `name:"old-token" deprecated:"use --token"`
+//	}
+//
+// This plugin isn't included in [Defaults], so existing CLIs aren't affected
+// until they opt in.
+func WithDeprecationPlugin[T any]() Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+
+		cli.kongOptions = append(
+			cli.kongOptions,
+			kong.PostBuild(func(k *kong.Kong) error {
+				return hideDeprecations(k.Model.Node)
+			}),
+			kong.Resolvers(kong.ResolverFunc(func(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+				if _, v, ok := aliasEnvValue(cli.getenv, flag); ok {
+					return v, nil
+				}
+				return nil, nil
+			})),
+			kong.WithAfterApply(func(kctx *kong.Context) error {
+				if initialized.Swap(true) {
+					return nil
+				}
+
+				logger := cli.logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+
+				var errs []error
+				for _, d := range usedDeprecations(kctx, cli.getenv) {
+					if d.RemovedIn != "" {
+						if removed, err := cli.version.AtLeast(d.RemovedIn); err == nil && removed {
+							errs = append(errs, d.removedError())
+							continue
+						}
+					}
+
+					if cli.strictDeprecations {
+						errs = append(errs, errors.New(d.String()))
+						continue
+					}
+
+					logger.Warn(d.String(), "kind", d.Kind, "name", d.Name, "replacement", d.Message)
+				}
+
+				return errors.Join(errs...)
+			}),
+		)
+	}
+}
+
+// hideDeprecations hides deprecated flags and commands from help output, and
+// validates the related struct tags.
+func hideDeprecations(node *kong.Node) error {
+	if isDeprecated(node.Tag) {
+		node.Hidden = true
+	}
+
+	for _, d := range Deprecations(node) {
+		if d.node != node {
+			continue
+		}
+
+		if d.RemovedIn != "" {
+			if _, err := ParseSemVer(d.RemovedIn); err != nil {
+				return fmt.Errorf("invalid removed_in tag for %s %s: %w", d.Kind, d.Name, err)
+			}
+		}
+
+		switch d.Kind {
+		case DeprecatedFlag:
+			d.flag.Hidden = true
+		case DeprecatedEnv:
+			if len(d.flag.Tag.Envs) == 0 {
+				return fmt.Errorf("flag --%s has an alias_env tag, but no env tag", d.flag.Name)
+			}
+		}
+	}
+
+	for _, child := range node.Children {
+		if err := hideDeprecations(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aliasEnvValue returns the first set deprecated "alias_env" environment
+// variable of the flag, and its value, if none of the flag's own environment
+// variables (including "_FILE" variants, see [WithFileEnvVars]) are set.
+func aliasEnvValue(lookupEnv lookupEnvFunc, flag *kong.Flag) (env, value string, ok bool) {
+	aliases := aliasEnvs(flag)
+	if len(aliases) == 0 {
+		return "", "", false
+	}
+
+	for _, env = range flag.Envs {
+		if _, ok = lookupEnv(env); ok {
+			return "", "", false
+		}
+	}
+
+	for _, env = range aliases {
+		if value, ok = lookupEnv(env); ok {
+			return env, value, true
+		}
+	}
+	return "", "", false
+}
+
+// usedDeprecations returns the deprecations which are used by the parsed
+// command line and environment.
+func usedDeprecations(kctx *kong.Context, lookupEnv lookupEnvFunc) []Deprecation {
+	active := kctx.Flags()
+
+	isEnvSet := func(env string) bool {
+		_, ok := lookupEnv(env)
+		return ok
+	}
+
+	var results []Deprecation
+	for _, d := range Deprecations(kctx.Model.Node) {
+		var used bool
+
+		switch d.Kind {
+		case DeprecatedCommand:
+			used = slices.ContainsFunc(kctx.Path, func(p *kong.Path) bool { return p.Command == d.node })
+		case DeprecatedFlag:
+			used = slices.ContainsFunc(kctx.Path, func(p *kong.Path) bool { return p.Flag == d.flag }) ||
+				(slices.Contains(active, d.flag) && slices.ContainsFunc(d.flag.Tag.Envs, isEnvSet))
+		case DeprecatedEnv:
+			// Only report the alias if it supplied the value, rather than the
+			// command line or the flag's own environment variables.
+			if !slices.Contains(active, d.flag) || slices.ContainsFunc(kctx.Path, func(p *kong.Path) bool {
+				return p.Flag == d.flag && !p.Resolved
+			}) {
+				break
+			}
+			env, _, ok := aliasEnvValue(lookupEnv, d.flag)
+			used = ok && env == d.Name
+		}
+
+		if used {
+			results = append(results, d)
+		}
+	}
+	return results
+}
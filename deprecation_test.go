@@ -0,0 +1,224 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+type testDeprecationFlags struct {
+	Token    string `name:"token" env:"TEST_APP_TOKEN" alias_env:"TEST_TOKEN" removed_in:"v2.0.0" help:"api token"`
+	OldToken string `name:"old-token" deprecated:"use --token" help:"api token"`
+
+	Get   struct{} `cmd:"" help:"get an item"`
+	Fetch struct{} `cmd:"" deprecated:"use get" help:"get an item"`
+}
+
+func runDeprecationCLI(t *testing.T, version string, strict bool, args ...string) (cli *CLI[testDeprecationFlags], out, logs string, code int) {
+	t.Helper()
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = append([]string{"testapp"}, args...)
+
+	var logBuf bytes.Buffer
+	oldLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(oldLogger) })
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logBuf, nil)))
+
+	var buf bytes.Buffer
+	code = -1
+
+	options := []Option[testDeprecationFlags]{
+		WithKongOptions[testDeprecationFlags](
+			kong.Writers(&buf, &buf),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithAppInfo[testDeprecationFlags](AppInfo{Version: version}),
+		WithDeprecationPlugin[testDeprecationFlags](),
+	}
+	if strict {
+		options = append(options, WithStrictDeprecations[testDeprecationFlags]())
+	}
+
+	cli = New(options...)
+	return cli, buf.String(), logBuf.String(), code
+}
+
+func TestDeprecationWarnings(t *testing.T) {
+	t.Setenv("TEST_TOKEN", "secret")
+
+	cli, _, logs, code := runDeprecationCLI(t, "v1.0.0", false, "fetch", "--old-token", "foo")
+	if code != -1 {
+		t.Fatalf("expected no exit, got exit code %d", code)
+	}
+
+	if cli.Flags.Token != "secret" {
+		t.Fatalf("expected alias_env to set --token, got %q", cli.Flags.Token)
+	}
+	if cli.Flags.OldToken != "foo" {
+		t.Fatalf("expected deprecated flag to still work, got %q", cli.Flags.OldToken)
+	}
+
+	expected := []string{
+		"command fetch is deprecated: use get",
+		"flag --old-token is deprecated: use --token",
+		"environment variable TEST_TOKEN (of --token) is deprecated and will be removed in v2.0.0: use TEST_APP_TOKEN",
+	}
+
+	for _, e := range expected {
+		if strings.Count(logs, e) != 1 {
+			t.Fatalf("expected %q to be logged once, got:\n%s", e, logs)
+		}
+	}
+
+	if _, ok := os.LookupEnv("TEST_APP_TOKEN"); ok {
+		t.Fatal("expected alias_env to not modify the environment")
+	}
+
+	_, _, logs, _ = runDeprecationCLI(t, "v1.0.0", false, "get")
+	if !strings.Contains(logs, "TEST_TOKEN") || strings.Contains(logs, "--old-token") {
+		t.Fatalf("expected only alias_env warning, got:\n%s", logs)
+	}
+
+	// The alias isn't used when the primary environment variable or the flag is
+	// set.
+	_, _, logs, _ = runDeprecationCLI(t, "v1.0.0", false, "get", "--token", "foo")
+	if strings.Contains(logs, "TEST_TOKEN") {
+		t.Fatalf("expected no alias_env warning with --token, got:\n%s", logs)
+	}
+
+	t.Setenv("TEST_APP_TOKEN", "primary")
+
+	cli, _, logs, _ = runDeprecationCLI(t, "v1.0.0", false, "get")
+	if cli.Flags.Token != "primary" {
+		t.Fatalf("expected env to take precedence over alias_env, got %q", cli.Flags.Token)
+	}
+	if strings.Contains(logs, "TEST_TOKEN") {
+		t.Fatalf("expected no alias_env warning with TEST_APP_TOKEN set, got:\n%s", logs)
+	}
+}
+
+func TestDeprecationHelp(t *testing.T) {
+	_, out, _, _ := runDeprecationCLI(t, "v1.0.0", false, "--help")
+
+	if !strings.Contains(out, "--token") {
+		t.Fatalf("expected --token in help, got:\n%s", out)
+	}
+
+	for _, e := range []string{"--old-token", "fetch", "TEST_TOKEN\n"} {
+		if strings.Contains(out, e) {
+			t.Fatalf("expected %q to be hidden from help, got:\n%s", e, out)
+		}
+	}
+}
+
+func TestDeprecationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		strict  bool
+		env     string
+		args    []string
+		want    string
+	}{
+		{
+			name:    "strict",
+			version: "v1.0.0",
+			strict:  true,
+			args:    []string{"get", "--old-token", "foo"},
+			want:    "flag --old-token is deprecated: use --token",
+		},
+		{
+			name:    "removed",
+			version: "v2.1.0",
+			env:     "secret",
+			args:    []string{"get"},
+			want:    "environment variable TEST_TOKEN (of --token) was removed in v2.0.0: use TEST_APP_TOKEN",
+		},
+		{
+			name:    "removed-unset",
+			version: "v2.1.0",
+			args:    []string{"get"},
+		},
+		{
+			name:    "devel",
+			version: "(devel)",
+			env:     "secret",
+			args:    []string{"get"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("TEST_TOKEN", tt.env)
+			}
+
+			_, out, _, code := runDeprecationCLI(t, tt.version, tt.strict, tt.args...)
+
+			if tt.want == "" {
+				if code != -1 {
+					t.Fatalf("expected no exit, got exit code %d:\n%s", code, out)
+				}
+				return
+			}
+
+			if code != 1 {
+				t.Fatalf("expected exit code 1, got %d", code)
+			}
+
+			if !strings.Contains(out, tt.want) {
+				t.Fatalf("expected %q in output, got:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestDeprecationMarkdown(t *testing.T) {
+	cli, _, _, _ := runDeprecationCLI(t, "v1.0.0", false, "get")
+
+	out, err := cli.GenerateMarkdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"- [Deprecated](#deprecated)",
+		"## Deprecated",
+		"| `TEST_TOKEN`  | env var | -       | use TEST\\_APP\\_TOKEN | `v2.0.0`   |",
+		"| `--old-token` | flag    | -       | use \\-\\-token        | -          |",
+		"| `fetch`       | command | -       | use get              | -          |",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q to be in generated markdown, got:\n%s", e, out)
+		}
+	}
+
+	if strings.Contains(out, "## `$ testapp fetch`") {
+		t.Fatal("expected deprecated command to be excluded from the commands section")
+	}
+
+	spec := NewCLISpec(cli.Context.Model, cli.version)
+	if len(spec.Commands) != 2 || spec.Commands[1].Deprecated != "use get" {
+		t.Fatalf("expected deprecated command in spec, got %+v", spec.Commands)
+	}
+	if len(spec.Flags[1].DeprecatedEnvs) != 1 || spec.Flags[2].Deprecated != "use --token" {
+		t.Fatalf("expected deprecated flags in spec, got %+v", spec.Flags)
+	}
+}
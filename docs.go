@@ -0,0 +1,315 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+var htmlTemplates = htmltemplate.Must(
+	htmltemplate.New("").
+		Funcs(htmltemplate.FuncMap{
+			"join": tmplFuncMap["join"],
+			"dict": tmplFuncMap["dict"],
+		}).
+		ParseFS(templateDir, "templates/html/*.gohtml"),
+)
+
+// CLISpec is a stable, machine-readable description of the CLI surface (commands,
+// arguments, flags, environment variables, enums, defaults and groups), generated
+// from the kong model. Hidden commands and flags are excluded, unless they're
+// deprecated (see [WithDeprecationPlugin]), as they still work. Ordering matches
+// the order in which they're defined, so the JSON encoded spec is suitable for
+// diffing between releases. See [NewCLISpec].
+type CLISpec struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Usage       string        `json:"usage"`
+	Examples    []Example     `json:"examples,omitempty"`
+	Flags       []FlagSpec    `json:"flags,omitempty"`
+	Args        []ArgSpec     `json:"args,omitempty"`
+	Commands    []CommandSpec `json:"commands,omitempty"`
+}
+
+// CommandSpec describes a command. See [CLISpec].
+type CommandSpec struct {
+	Name       string        `json:"name"`
+	Path       string        `json:"path"`
+	Aliases    []string      `json:"aliases,omitempty"`
+	Help       string        `json:"help,omitempty"`
+	Detail     string        `json:"detail,omitempty"`
+	Usage      string        `json:"usage"`
+	Group      string        `json:"group,omitempty"`
+	Default    bool          `json:"default,omitempty"`
+	Deprecated string        `json:"deprecated,omitempty"`
+	RemovedIn  string        `json:"removed_in,omitempty"`
+	Examples   []Example     `json:"examples,omitempty"`
+	Flags      []FlagSpec    `json:"flags,omitempty"`
+	Args       []ArgSpec     `json:"args,omitempty"`
+	Commands   []CommandSpec `json:"commands,omitempty"`
+
+	// Anchor is the anchor ID used for the command in generated documentation.
+	Anchor string `json:"-"`
+}
+
+// FlagSpec describes a flag. See [CLISpec].
+type FlagSpec struct {
+	Name           string   `json:"name"`
+	Short          string   `json:"short,omitempty"`
+	Aliases        []string `json:"aliases,omitempty"`
+	Negation       string   `json:"negation,omitempty"`
+	Help           string   `json:"help,omitempty"`
+	Type           string   `json:"type"`
+	Placeholder    string   `json:"placeholder,omitempty"`
+	Default        string   `json:"default,omitempty"`
+	Envs           []string `json:"envs,omitempty"`
+	FileEnvs       []string `json:"file_envs,omitempty"`
+	Enum           []string `json:"enum,omitempty"`
+	Required       bool     `json:"required,omitempty"`
+	Group          string   `json:"group,omitempty"`
+	Xor            []string `json:"xor,omitempty"`
+	And            []string `json:"and,omitempty"`
+	Deprecated     string   `json:"deprecated,omitempty"`
+	DeprecatedEnvs []string `json:"deprecated_envs,omitempty"`
+	RemovedIn      string   `json:"removed_in,omitempty"`
+
+	// Anchor is the anchor ID used for the flag in generated documentation.
+	Anchor string `json:"-"`
+}
+
+// ArgSpec describes a positional argument. See [CLISpec].
+type ArgSpec struct {
+	Name     string   `json:"name"`
+	Help     string   `json:"help,omitempty"`
+	Type     string   `json:"type"`
+	Default  string   `json:"default,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// NewCLISpec generates a [CLISpec] from the kong model. The description is taken
+// from the app info rather than the model, as the model help may contain versions,
+// links, etc, which would result in diffs between builds.
+func NewCLISpec(model *kong.Application, version *Version) *CLISpec {
+	spec := &CLISpec{
+		Name:     model.Name,
+		Usage:    model.Name + model.Summary(),
+		Examples: NodeExamples(model.Node),
+		Flags:    newFlagSpecs(model.Node, model.Flags),
+		Args:     newArgSpecs(nodeArgs(model.Node)),
+		Commands: newCommandSpecs(model.Name, model.Node),
+	}
+
+	if version != nil && version.AppInfo != nil {
+		spec.Description = version.AppInfo.Description
+	}
+
+	return spec
+}
+
+func newCommandSpecs(appName string, node *kong.Node) []CommandSpec {
+	var results []CommandSpec
+	for _, child := range node.Children {
+		// Commands of branching arguments (see [nodeArgs]) are listed alongside
+		// the commands of the node.
+		if child.Type == kong.ArgumentNode {
+			results = append(results, newCommandSpecs(appName, child)...)
+			continue
+		}
+
+		if child.Type != kong.CommandNode || (child.Hidden && !isDeprecated(child.Tag)) {
+			continue
+		}
+
+		cmd := CommandSpec{
+			Name:     child.Name,
+			Path:     commandPath(child),
+			Aliases:  child.Aliases,
+			Help:     child.Help,
+			Detail:   child.Detail,
+			Usage:    appName + " " + child.Summary(),
+			Default:  node.DefaultCmd == child,
+			Examples: NodeExamples(child),
+			Flags:    newFlagSpecs(child, child.Flags),
+			Args:     newArgSpecs(nodeArgs(child)),
+			Commands: newCommandSpecs(appName, child),
+			Anchor:   "command-" + slugify(child.Path()),
+		}
+		if child.Group != nil {
+			cmd.Group = child.Group.Title
+		}
+		if child.Tag != nil {
+			cmd.Deprecated = child.Tag.Get("deprecated")
+			cmd.RemovedIn = child.Tag.Get("removed_in")
+		}
+		results = append(results, cmd)
+	}
+	return results
+}
+
+// commandPath returns the space-separated command names (and branching
+// arguments, e.g. "<name>") leading to the node. Unlike [kong.Node.Path],
+// aliases aren't included.
+func commandPath(node *kong.Node) string {
+	var names []string
+	for n := node; n != nil; n = n.Parent {
+		switch n.Type { //nolint:exhaustive
+		case kong.CommandNode:
+			names = append([]string{n.Name}, names...)
+		case kong.ArgumentNode:
+			names = append([]string{"<" + n.Name + ">"}, names...)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func newFlagSpecs(node *kong.Node, flags []*kong.Flag) []FlagSpec {
+	var results []FlagSpec
+	for _, flag := range flags {
+		if flag.Hidden && !isDeprecated(flag.Tag) {
+			continue
+		}
+		results = append(results, newFlagSpec(node, flag))
+	}
+	return results
+}
+
+func newFlagSpec(node *kong.Node, flag *kong.Flag) FlagSpec {
+	prefix := "flag-"
+	if path := node.Path(); path != "" {
+		prefix += path + "-"
+	}
+
+	spec := FlagSpec{
+		Name:     flag.Name,
+		Aliases:  flag.Aliases,
+		Help:     flag.Help,
+		Type:     valueType(flag.Value),
+		Default:  flag.Default,
+		Envs:     flag.Envs,
+		Enum:     enumSlice(flag.Value),
+		Required: flag.Required,
+		Xor:      flag.Xor,
+		And:      flag.And,
+		Anchor:   slugify(prefix + flag.Name),
+	}
+
+	if flag.Short != 0 {
+		spec.Short = string(flag.Short)
+	}
+
+	if !flag.IsBool() && (flag.Tag == nil || flag.Tag.Type != "counter") {
+		// Placeholders fall back to the default value, which is already
+		// included separately.
+		value := *flag.Value
+		value.HasDefault = false
+		placeholder := *flag
+		placeholder.Value = &value
+		spec.Placeholder = placeholder.FormatPlaceHolder()
+	}
+
+	if flag.Tag != nil {
+		switch flag.Tag.Negatable {
+		case "":
+		case "_":
+			spec.Negation = "no-" + flag.Name
+		default:
+			spec.Negation = flag.Tag.Negatable
+		}
+
+		// Display envs may include "<ENV>_FILE" variants, see [WithFileEnvVars].
+		spec.Envs = flag.Tag.Envs
+		for _, env := range flag.Envs {
+			if !slices.Contains(flag.Tag.Envs, env) {
+				spec.FileEnvs = append(spec.FileEnvs, env)
+			}
+		}
+
+		spec.Deprecated = flag.Tag.Get("deprecated")
+		spec.DeprecatedEnvs = aliasEnvs(flag)
+		spec.RemovedIn = flag.Tag.Get("removed_in")
+	}
+
+	if flag.Group != nil {
+		spec.Group = flag.Group.Title
+	}
+
+	return spec
+}
+
+// Usage returns the flag usage, e.g. "-f, --foo=STRING".
+func (f FlagSpec) Usage() string {
+	usage := "--" + f.Name
+	if f.Placeholder != "" {
+		usage += "=" + f.Placeholder
+	}
+	if f.Short != "" {
+		usage = "-" + f.Short + ", " + usage
+	}
+	return usage
+}
+
+func newArgSpecs(args []*kong.Positional) []ArgSpec {
+	var results []ArgSpec
+	for _, arg := range args {
+		results = append(results, ArgSpec{
+			Name:     arg.Name,
+			Help:     arg.Help,
+			Type:     valueType(arg),
+			Default:  arg.Default,
+			Enum:     enumSlice(arg),
+			Required: arg.Required,
+		})
+	}
+	return results
+}
+
+func valueType(v *kong.Value) string {
+	if !v.Target.IsValid() {
+		return ""
+	}
+	return v.Target.Type().String()
+}
+
+func enumSlice(v *kong.Value) []string {
+	if v.Enum == "" {
+		return nil
+	}
+	return v.EnumSlice()
+}
+
+// GenerateJSON generates the JSON encoded [CLISpec] for the CLI.
+func (m *MarkdownCommand) GenerateJSON(model *kong.Application, version *Version) (string, error) {
+	b, err := json.MarshalIndent(NewCLISpec(model, version), "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// GenerateHTML generates a single self-contained HTML page documenting the CLI,
+// with client-side search. Anchors match those used in the generated markdown
+// (e.g. "#command-<path>" and "#flag-<path>-<name>"), so links can be shared
+// between the two.
+func (m *MarkdownCommand) GenerateHTML(model *kong.Application, version *Version) (string, error) {
+	buf := bytes.NewBuffer(nil)
+
+	err := htmlTemplates.ExecuteTemplate(buf, "docs.gohtml", map[string]any{
+		"Spec":    NewCLISpec(model, version),
+		"AppInfo": version.AppInfo,
+		"Version": version,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
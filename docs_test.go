@@ -0,0 +1,162 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+type testDocsFlags struct {
+	Required string `name:"required" required:"" help:"required flag"`
+
+	Foo struct {
+		Bar struct {
+			Baz    string `name:"baz" short:"b" env:"BAZ" default:"qux" enum:"qux,quux" help:"baz flag"`
+			Color  bool   `name:"color" negatable:"" help:"colorize output"`
+			Secret string `name:"secret" hidden:""`
+
+			Target string `arg:"" optional:"" help:"target to use"`
+		} `cmd:"" aliases:"b" help:"bar command"`
+	} `cmd:"" help:"foo command"`
+}
+
+func generateDocs(t *testing.T, format string) string {
+	t.Helper()
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"clix", "generate-docs", "--format", format}
+
+	var stdout strings.Builder
+
+	New(
+		WithKongOptions[testDocsFlags](
+			kong.DynamicCommand(
+				"generate-markdown",
+				"generate documentation and write to stdout",
+				"",
+				&MarkdownCommand{DisableExit: true},
+				"hidden",
+				`aliases:"generate-docs"`,
+			),
+			kong.Writers(&stdout, &strings.Builder{}),
+			kong.Exit(func(int) {}),
+		),
+	)
+
+	return stdout.String()
+}
+
+func TestGenerateDocsJSON(t *testing.T) {
+	var spec CLISpec
+	if err := json.NewDecoder(strings.NewReader(generateDocs(t, DocsFormatJSON))).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Name != "clix" || len(spec.Commands) != 1 {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+
+	if spec.Flags[1].Name != "required" || !spec.Flags[1].Required {
+		t.Fatalf("expected required global flag, got %+v", spec.Flags)
+	}
+
+	bar := spec.Commands[0].Commands[0]
+	if bar.Path != "foo bar" || len(bar.Aliases) != 1 || bar.Aliases[0] != "b" {
+		t.Fatalf("unexpected command: %+v", bar)
+	}
+
+	if len(bar.Flags) != 2 {
+		t.Fatalf("expected hidden flag to be excluded, got %+v", bar.Flags)
+	}
+
+	baz := bar.Flags[0]
+	if baz.Short != "b" || baz.Default != "qux" || baz.Placeholder != "STRING" ||
+		strings.Join(baz.Envs, ",") != "BAZ" || strings.Join(baz.Enum, ",") != "qux,quux" {
+		t.Fatalf("unexpected flag: %+v", baz)
+	}
+
+	if bar.Flags[1].Negation != "no-color" || bar.Flags[1].Placeholder != "" {
+		t.Fatalf("unexpected negatable flag: %+v", bar.Flags[1])
+	}
+
+	if len(bar.Args) != 1 || bar.Args[0].Name != "target" || bar.Args[0].Required {
+		t.Fatalf("unexpected args: %+v", bar.Args)
+	}
+}
+
+func TestNewCLISpecBranchingArgs(t *testing.T) {
+	type Flags struct {
+		User struct {
+			Name struct {
+				Name string `arg:"" help:"name of the user"`
+
+				Delete struct{} `cmd:"" help:"delete the user"`
+			} `arg:""`
+		} `cmd:"" help:"manage users"`
+	}
+
+	k, err := kong.New(&Flags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := NewCLISpec(k.Model, nil)
+
+	user := spec.Commands[0]
+	if len(user.Args) != 1 || user.Args[0].Name != "name" || !user.Args[0].Required {
+		t.Fatalf("expected branching argument in args, got %+v", user.Args)
+	}
+
+	if len(user.Commands) != 1 || user.Commands[0].Path != "user <name> delete" {
+		t.Fatalf("expected command of branching argument, got %+v", user.Commands)
+	}
+
+	current := *spec
+	current.Commands = []CommandSpec{user}
+	current.Commands[0].Commands = nil
+
+	issues := CheckCompatibility(spec, &current)
+	if len(issues) != 1 || issues[0].Kind != CompatCommandRemoved {
+		t.Fatalf("expected removal of command to be reported, got %v", issues)
+	}
+}
+
+func TestGenerateDocsHTML(t *testing.T) {
+	out := generateDocs(t, DocsFormatHTML)
+
+	expected := []string{
+		"<!DOCTYPE html>",
+		`id="search"`,
+		`<section id="command-foo-bar-b" class="searchable">`,
+		`href="#command-foo-bar-b"`,
+		`<tr id="flag-foo-bar-b-baz">`,
+		`<tr id="flag-required">`,
+		"&lt;target&gt;",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q to be in generated HTML, got:\n%s", e, out)
+		}
+	}
+
+	if strings.Contains(out, "secret") {
+		t.Fatal("expected hidden flag to be excluded")
+	}
+
+	// Anchors should match those in the generated markdown.
+	md := generateDocs(t, DocsFormatMarkdown)
+	for _, e := range []string{`id="command-foo-bar-b"`, `id="flag-foo-bar-b-baz"`} {
+		if !strings.Contains(md, e) {
+			t.Fatalf("expected %q to be in generated markdown", e)
+		}
+	}
+}
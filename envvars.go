@@ -5,7 +5,10 @@
 package clix
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/alecthomas/kong"
@@ -15,41 +18,191 @@ import (
 // WithEnvFiles loads environment variables from ".env" style files from the
 // provided paths. If no paths are provided, it will load from the current
 // working directory as ".env", but will not return an error if the file has
-// access issues/doesn't exist.
+// access issues/doesn't exist. When used with [WithReloadPlugin], the files are
+// also watched for changes.
 func WithEnvFiles[T any](paths ...string) Option[T] {
 	var initialized atomic.Bool
 	return func(cli *CLI[T]) {
 		if initialized.Load() {
 			return
 		}
+
+		cli.envFiles = &envFileLoader{paths: paths}
+		if len(paths) == 0 {
+			cli.envFiles.paths = []string{".env"}
+			cli.envFiles.optional = true
+		}
+
 		cli.kongOptions = append(cli.kongOptions, kong.WithBeforeReset(func() error {
 			if initialized.Swap(true) {
 				return nil
 			}
-			var vars map[string]string
-			var err error
-
-			if len(paths) > 0 {
-				vars, err = dotenv.ParseFiles(paths...)
-				if err != nil {
-					return err
-				}
-			} else {
-				vars, err = dotenv.ParseFiles(".env")
-				if err != nil {
-					if _, ok := dotenv.IsFileAccessError(err); ok {
-						return nil
-					}
-					return err
-				}
-			}
-			for k, v := range vars {
-				err = os.Setenv(k, v)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
+			return cli.envFiles.load()
 		}))
 	}
 }
+
+// envFileLoader loads ".env" style files into the environment, keeping track of
+// which variables it has set, so they can be updated/removed when reloading.
+type envFileLoader struct {
+	paths    []string
+	optional bool // If true, file access errors are ignored.
+
+	mu   sync.Mutex
+	vars map[string]string // Variables set in the last load.
+}
+
+func (l *envFileLoader) load() error {
+	vars, err := l.parse()
+	if err != nil {
+		return err
+	}
+	return l.apply(vars)
+}
+
+// parse parses the files, without applying them to the environment.
+func (l *envFileLoader) parse() (map[string]string, error) {
+	vars, err := dotenv.ParseFiles(l.paths...)
+	if err != nil {
+		if _, ok := dotenv.IsFileAccessError(err); !ok || !l.optional {
+			return nil, err
+		}
+		vars = map[string]string{}
+	}
+	return vars, nil
+}
+
+// overlay returns a function which looks up environment variables as they would
+// be after applying vars with [envFileLoader.apply], without modifying the
+// environment.
+func (l *envFileLoader) overlay(vars map[string]string) lookupEnvFunc {
+	l.mu.Lock()
+	prev := l.vars
+	l.mu.Unlock()
+
+	return func(key string) (string, bool) {
+		if v, ok := vars[key]; ok {
+			return v, true
+		}
+
+		v, ok := os.LookupEnv(key)
+		if pv, set := prev[key]; set && ok && v == pv {
+			return "", false
+		}
+		return v, ok
+	}
+}
+
+// apply sets vars in the environment, removing any variables set by the previous
+// apply which are no longer present.
+func (l *envFileLoader) apply(vars map[string]string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Remove any variables we previously set, which are no longer in the files,
+	// as long as they haven't been changed by something else.
+	for k, v := range l.vars {
+		if _, ok := vars[k]; ok {
+			continue
+		}
+		if cv, ok := os.LookupEnv(k); ok && cv == v {
+			err := os.Unsetenv(k)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for k, v := range vars {
+		err := os.Setenv(k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	l.vars = vars
+	return nil
+}
+
+// WithFileEnvVars adds "<ENV>_FILE" support for every flag which has an "env"
+// tag, similar to the convention used by many Docker images (e.g.
+// "FOO_FILE=/run/secrets/foo" as an alternative to "FOO"). The file contents are
+// read and trimmed of surrounding whitespace, and used as the value of the flag,
+// without modifying the environment. If both the original environment variable
+// and the "_FILE" variant are set, an error is returned. Files are read on every
+// parse, so they are also re-read by [CLI.Reload].
+//
+// The "_FILE" variants are also included in the generated documentation (see
+// [FlagSpec.FileEnvs]).
+func WithFileEnvVars[T any]() Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Swap(true) {
+			return
+		}
+		cli.kongOptions = append(
+			cli.kongOptions,
+			kong.WithBeforeReset(func(kctx *kong.Context) error {
+				addFileEnvVars(kctx.Model.Node)
+				return nil
+			}),
+			kong.Resolvers(kong.ResolverFunc(func(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+				return resolveFileEnvVar(cli.getenv, flag)
+			})),
+		)
+	}
+}
+
+// fileEnvSuffix is the suffix used for environment variables which reference
+// a file containing the value of the original environment variable.
+const fileEnvSuffix = "_FILE"
+
+// addFileEnvVars walks all flags within the node (and its children), adding the
+// "<ENV>_FILE" variants to the display envs (used by help/docs). Tag.Envs is what
+// kong uses to resolve values, which shouldn't include the file variants.
+func addFileEnvVars(node *kong.Node) {
+	for _, flag := range node.Flags {
+		if flag.Tag == nil || len(flag.Tag.Envs) == 0 {
+			continue
+		}
+
+		envs := make([]string, 0, len(flag.Tag.Envs)*2)
+		envs = append(envs, flag.Tag.Envs...)
+		for _, env := range flag.Tag.Envs {
+			envs = append(envs, env+fileEnvSuffix)
+		}
+		flag.Envs = envs
+	}
+
+	for _, child := range node.Children {
+		addFileEnvVars(child)
+	}
+}
+
+// resolveFileEnvVar returns the contents of the file referenced by the first set
+// "<ENV>_FILE" environment variable of the flag, or nil if none are set.
+func resolveFileEnvVar(lookupEnv lookupEnvFunc, flag *kong.Flag) (any, error) {
+	if flag.Tag == nil {
+		return nil, nil
+	}
+
+	for _, env := range flag.Tag.Envs {
+		fileEnv := env + fileEnvSuffix
+
+		path, ok := lookupEnv(fileEnv)
+		if !ok {
+			continue
+		}
+
+		if _, ok = lookupEnv(env); ok {
+			return nil, fmt.Errorf("both %s and %s are set, only one may be used", env, fileEnv)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileEnv, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestWithFileEnvVars(t *testing.T) {
+	type Flags struct {
+		Secret string `name:"secret" env:"CLIX_TEST_SECRET" help:"secret"`
+	}
+
+	fn := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(fn, []byte("  hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CLIX_TEST_SECRET_FILE", fn)
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	cli := New(WithFileEnvVars[Flags]())
+
+	if cli.Flags.Secret != "hunter2" {
+		t.Fatalf("expected secret to be loaded from file, got %q", cli.Flags.Secret)
+	}
+
+	if _, ok := os.LookupEnv("CLIX_TEST_SECRET"); ok {
+		t.Fatal("expected CLIX_TEST_SECRET to not be set in the environment")
+	}
+
+	spec := NewCLISpec(cli.Context.Model, cli.version)
+	for _, flag := range spec.Flags {
+		if flag.Name != "secret" {
+			continue
+		}
+		if strings.Join(flag.Envs, ",") != "CLIX_TEST_SECRET" || strings.Join(flag.FileEnvs, ",") != "CLIX_TEST_SECRET_FILE" {
+			t.Fatalf("expected file envs to be separate from envs, got %+v", flag)
+		}
+	}
+
+	md, err := cli.GenerateMarkdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(md, "CLIX_TEST_SECRET_FILE") {
+		t.Fatalf("expected markdown to include CLIX_TEST_SECRET_FILE, got:\n%s", md)
+	}
+}
+
+func TestWithFileEnvVarsConflict(t *testing.T) {
+	type Flags struct {
+		Secret string `name:"secret" env:"CLIX_TEST_SECRET" help:"secret"`
+	}
+
+	fn := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(fn, []byte("hunter2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CLIX_TEST_SECRET", "foo")
+	t.Setenv("CLIX_TEST_SECRET_FILE", fn)
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	var buf strings.Builder
+	var code int
+
+	New(
+		WithKongOptions[Flags](
+			kong.Writers(&buf, &buf),
+			kong.Exit(func(c int) { code = c }),
+		),
+		WithFileEnvVars[Flags](),
+	)
+
+	if code == 0 {
+		t.Fatal("expected non-zero exit code when both env var and file env var are set")
+	}
+
+	if !strings.Contains(buf.String(), "CLIX_TEST_SECRET_FILE") {
+		t.Fatalf("expected error to reference CLIX_TEST_SECRET_FILE, got:\n%s", buf.String())
+	}
+}
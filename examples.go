@@ -0,0 +1,229 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// Example is a worked example of invoking a command, rendered in help output and
+// generated documentation. See [Exampler].
+type Example struct {
+	// Description of what the example does.
+	Description string `json:"description,omitempty"`
+
+	// Command is the command line, excluding the application name (e.g.
+	// "users list --all"). Quotes can be used for arguments containing spaces.
+	Command string `json:"command"`
+
+	// Output is optional example output of the command.
+	Output string `json:"output,omitempty"`
+}
+
+// Exampler can be implemented by commands (or the flags struct provided to [New],
+// for application-level examples) to provide worked examples, which are rendered
+// in help output and generated documentation. Alternatively, examples can be
+// provided with the "examples" struct tag, separated by ";", with an optional
+// description after " # ", e.g.:
+//
+//	List struct{} `cmd:"" examples:"list --all # list all items;list foo"`
+type Exampler interface {
+	Examples() []Example
+}
+
+// Examples returns the application-level examples, if the flags struct
+// implements [Exampler].
+func (cli *CLI[T]) Examples() []Example {
+	if e, ok := any(cli.Flags).(Exampler); ok {
+		return e.Examples()
+	}
+	return nil
+}
+
+// NodeExamples returns the examples of the node (command or application), from
+// both the [Exampler] interface and the "examples" struct tag.
+func NodeExamples(node *kong.Node) []Example {
+	var examples []Example
+
+	if node.Target.IsValid() {
+		target := node.Target
+		if target.CanAddr() {
+			target = target.Addr()
+		}
+		if e, ok := target.Interface().(Exampler); ok {
+			examples = append(examples, e.Examples()...)
+		}
+	}
+
+	if node.Tag != nil {
+		for _, raw := range strings.Split(node.Tag.Get("examples"), ";") {
+			command, description, _ := strings.Cut(raw, " # ")
+			if command = strings.TrimSpace(command); command == "" {
+				continue
+			}
+			examples = append(examples, Example{
+				Description: strings.TrimSpace(description),
+				Command:     command,
+			})
+		}
+	}
+
+	return examples
+}
+
+// writeExamples writes an "Examples:" help section for the examples.
+func writeExamples(w io.Writer, appName string, examples []Example) error {
+	if len(examples) == 0 {
+		return nil
+	}
+
+	var buf strings.Builder
+	buf.WriteString("\nExamples:\n")
+	for i, e := range examples {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if e.Description != "" {
+			buf.WriteString("  # " + e.Description + "\n")
+		}
+		buf.WriteString("  $ " + appName + " " + e.Command + "\n")
+		for line := range strings.Lines(e.Output) {
+			buf.WriteString("  " + strings.TrimRight(line, "\n") + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// VerifyExamples checks that all examples of the application and its commands (see
+// [Exampler]) parse successfully and pass validation, without running any hooks
+// or commands. Environment variables are ignored, so examples must be complete
+// on their own. Useful in tests, to make sure examples don't go stale, e.g.:
+//
+//	func TestExamples(t *testing.T) {
+//		if err := cli.VerifyExamples(); err != nil {
+//			t.Fatal(err)
+//		}
+//	}
+func (cli *CLI[T]) VerifyExamples() error {
+	if cli.Context == nil {
+		return errors.New("context not initialized, must parse first")
+	}
+
+	k, err := kong.New(&CLI[T]{Plugins: clonePlugins(cli.Plugins), Flags: new(T)}, cli.kongOptions...)
+	if err != nil {
+		return err
+	}
+
+	// Resolvers registered by plugins (e.g. [WithFileEnvVars]) read the
+	// environment through the original CLI.
+	if cli.reloader != nil {
+		cli.reloader.mu.Lock()
+		defer cli.reloader.mu.Unlock()
+	}
+	cli.lookupEnv = noEnv
+	defer func() { cli.lookupEnv = nil }()
+
+	var errs []error
+
+	_ = kong.Visit(k.Model.Node, func(n kong.Visitable, next kong.Next) error {
+		node, ok := n.(*kong.Node)
+		if !ok || (node.Type != kong.ApplicationNode && node.Type != kong.CommandNode) {
+			return next(nil)
+		}
+
+		for _, e := range NodeExamples(node) {
+			if err := verifyExample(k, e); err != nil {
+				errs = append(errs, fmt.Errorf("example %q: %w", e.Command, err))
+			}
+		}
+		return next(nil)
+	})
+
+	return errors.Join(errs...)
+}
+
+func verifyExample(k *kong.Kong, e Example) error {
+	args, err := splitArgs(e.Command)
+	if err != nil {
+		return err
+	}
+
+	kctx, err := kong.Trace(k, args)
+	if err != nil {
+		return err
+	}
+	if kctx.Error != nil {
+		return kctx.Error
+	}
+	if err = kctx.Reset(); err != nil {
+		return err
+	}
+	if err = resetEnvValues(k.Model.Node, noEnv); err != nil {
+		return err
+	}
+	if err = kctx.Resolve(); err != nil {
+		return err
+	}
+	if _, err = kctx.Apply(); err != nil {
+		return err
+	}
+	return kctx.Validate()
+}
+
+// noEnv is a [lookupEnvFunc] for an empty environment.
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+// splitArgs splits a command line into arguments, supporting single quotes,
+// double quotes and backslash escapes.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
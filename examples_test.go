@@ -0,0 +1,133 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+type testExampleFlags struct {
+	Get struct {
+		Name   string `arg:"" help:"name of the item"`
+		Format string `name:"format" enum:"json,text" default:"text"`
+	} `cmd:"" help:"get an item" examples:"get foo --format=json # get foo as JSON;get 'foo bar'"`
+}
+
+func (f *testExampleFlags) Examples() []Example {
+	return []Example{{Description: "get an item", Command: "get foo", Output: "foo: bar"}}
+}
+
+func newExampleCLI(t *testing.T, args ...string) (cli *CLI[testExampleFlags], stdout string) {
+	t.Helper()
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = append([]string{"testapp"}, args...)
+
+	var buf bytes.Buffer
+	cli = New(
+		WithKongOptions[testExampleFlags](
+			kong.Writers(&buf, &bytes.Buffer{}),
+			kong.Exit(func(int) {}),
+		),
+	)
+	return cli, buf.String()
+}
+
+func TestNodeExamples(t *testing.T) {
+	cli, _ := newExampleCLI(t, "get", "foo")
+
+	app := NodeExamples(cli.Context.Model.Node)
+	if len(app) != 1 || app[0].Output != "foo: bar" {
+		t.Fatalf("expected application examples from Examples method, got %+v", app)
+	}
+
+	get := NodeExamples(cli.Context.Model.Children[0])
+	want := []Example{
+		{Description: "get foo as JSON", Command: "get foo --format=json"},
+		{Command: "get 'foo bar'"},
+	}
+	if !slices.Equal(get, want) {
+		t.Fatalf("expected %+v, got %+v", want, get)
+	}
+
+	if err := cli.VerifyExamples(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyExamples(t *testing.T) {
+	type Flags struct {
+		Get struct {
+			Name   string `arg:"" help:"name of the item"`
+			Format string `name:"format" env:"TEST_FORMAT" enum:"json,text" default:"text"`
+		} `cmd:"" examples:"get foo --format=yaml;get;get foo;get foo --log.level=debug"`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "get", "foo"}
+
+	cli := New(
+		WithKongOptions[Flags](kong.Exit(func(int) {})),
+		WithLoggingPlugin[Flags](false, nil),
+	)
+
+	// Examples shouldn't depend on the environment.
+	t.Setenv("TEST_FORMAT", "invalid")
+
+	err := cli.VerifyExamples()
+	if err == nil {
+		t.Fatal("expected invalid examples to fail verification")
+	}
+
+	for _, e := range []string{`example "get foo --format=yaml"`, `example "get"`} {
+		if !strings.Contains(err.Error(), e) {
+			t.Fatalf("expected %q to be in error, got: %v", e, err)
+		}
+	}
+
+	for _, e := range []string{`example "get foo":`, `example "get foo --log.level=debug"`} {
+		if strings.Contains(err.Error(), e) {
+			t.Fatalf("expected valid example to pass verification, got: %v", err)
+		}
+	}
+}
+
+func TestExamplesHelp(t *testing.T) {
+	_, out := newExampleCLI(t, "get", "--help")
+
+	expected := "\nExamples:\n" +
+		"  # get foo as JSON\n" +
+		"  $ testapp get foo --format=json\n" +
+		"\n" +
+		"  $ testapp get 'foo bar'\n"
+
+	if !strings.Contains(out, expected) {
+		t.Fatalf("expected help to contain:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(`get "foo bar" 'baz qux' a\ b --x=""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"get", "foo bar", "baz qux", "a b", "--x="}
+	if !slices.Equal(args, want) {
+		t.Fatalf("expected %q, got %q", want, args)
+	}
+
+	if _, err = splitArgs(`get "foo`); err == nil {
+		t.Fatal("expected error for unterminated quote")
+	}
+}
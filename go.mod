@@ -5,4 +5,8 @@ go 1.25.1
 require (
 	github.com/alecthomas/kong v1.15.0
 	github.com/lmittmann/tint v1.1.3
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/term v0.45.0
 )
+
+require golang.org/x/sys v0.47.0 // indirect
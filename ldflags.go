@@ -0,0 +1,49 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"strings"
+)
+
+// Build information which can be injected at build time using -ldflags, for
+// builds outside of a VCS checkout (where Go can't embed VCS information). For
+// example:
+//
+//	go build -ldflags "\
+//		-X github.com/lrstanley/clix/v2.buildVersion=v1.2.3 \
+//		-X github.com/lrstanley/clix/v2.buildCommit=$(git rev-parse HEAD) \
+//		-X github.com/lrstanley/clix/v2.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ) \
+//		-X github.com/lrstanley/clix/v2.buildBranch=main \
+//		-X github.com/lrstanley/clix/v2.buildMetadata=pipeline=1234,builder=ci"
+//
+// Values are merged by [GetVersionInfo] with the following precedence: explicit
+// [AppInfo] (see [WithAppInfo]) > ldflags > VCS information > module information.
+var (
+	buildVersion string // Overrides the module/VCS version.
+	buildCommit  string // Overrides the "vcs.revision" build setting.
+	buildDate    string // Overrides the "vcs.time" build setting.
+	buildBranch  string // VCS branch, which Go doesn't embed.
+
+	// buildMetadata is a comma-separated list of additional key=value pairs,
+	// which are included in [Version.Settings].
+	buildMetadata string
+)
+
+// ldflagsSettings returns the build settings provided through ldflags.
+func ldflagsSettings() []BuildSetting {
+	var settings []BuildSetting
+
+	for pair := range strings.SplitSeq(buildMetadata, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+
+		settings = append(settings, BuildSetting{Key: key, Value: strings.TrimSpace(value)})
+	}
+
+	return settings
+}
@@ -6,9 +6,9 @@ package clix
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -29,6 +29,22 @@ func WithLoggingHandlerOptions[T any](opts *slog.HandlerOptions) Option[T] {
 	}
 }
 
+// WithLogHandler uses the provided [log/slog.Handler], rather than creating one
+// from the flags of [WithLoggingPlugin] (which are still accepted, but have no
+// effect). Useful in tests, to capture log records. The handler is not set as the
+// global logger.
+func WithLogHandler[T any](handler slog.Handler) Option[T] {
+	return func(cli *CLI[T]) {
+		cli.logHandler = handler
+		cli.logger = slog.New(handler)
+		cli.kongOptions = append(
+			cli.kongOptions,
+			kong.Bind(cli.logHandler),
+			kong.Bind(cli.logger),
+		)
+	}
+}
+
 // WithLoggingPlugin adds the logging plugin to the CLI. This includes flags
 // for controlling [log/slog] logging levels, logging to files, JSON output, and
 // supports setting the global slog logger. You can access the resulting
@@ -53,10 +69,21 @@ func WithLoggingPlugin[T any](global bool, opts *slog.HandlerOptions) Option[T]
 		flags.Logging = &LoggingPlugin{}
 		cli.Plugins = append(cli.Plugins, &flags)
 		cli.kongOptions = append(cli.kongOptions, kong.WithAfterApply(func(kctx *kong.Context) error {
-			if initialized.Swap(true) || cli.logHandler != nil {
+			if initialized.Swap(true) {
+				return nil
+			}
+
+			// Kong allocates embedded structs while building the model, so only
+			// reference them once parsed.
+			cli.logging = flags.Logging
+
+			if cli.logHandler != nil {
 				return nil
 			}
 
+			flags.Logging.term = cli.Term()
+			flags.Logging.stderr = cli.errWriter(kctx)
+
 			logger, err := flags.Logging.CreateHandler(cli.Debug, global, cli.logHandlerOptions)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "error creating logger: %v\n", err)
@@ -107,6 +134,9 @@ type LoggingPlugin struct {
 
 	// Path is the path to the log file.
 	Path string `name:"log.path" env:"LOG_PATH" type:"path" help:"path to log file (disables stderr logging)"`
+
+	term   *Terminal
+	stderr io.Writer
 }
 
 func (l *LoggingPlugin) GetLevel() slog.Level {
@@ -145,7 +175,16 @@ func (l *LoggingPlugin) CreateHandler(isDebug, setGlobal bool, opts *slog.Handle
 		opts.Level = level
 	}
 
-	noColor, _ := strconv.ParseBool(os.Getenv("NO_COLOR"))
+	t := l.term
+	if t == nil {
+		t = NewTerminal(ColorAuto)
+	}
+	noColor := !t.StderrColor()
+
+	var w io.Writer = stderr
+	if l.stderr != nil {
+		w = l.stderr
+	}
 
 	switch {
 	case l.Path != "":
@@ -160,12 +199,12 @@ func (l *LoggingPlugin) CreateHandler(isDebug, setGlobal bool, opts *slog.Handle
 	case level == -1:
 		handler = slog.DiscardHandler
 	case l.JSON:
-		handler = slog.NewJSONHandler(os.Stderr, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	case noColor:
-		handler = slog.NewTextHandler(os.Stderr, opts)
+		handler = slog.NewTextHandler(w, opts)
 	default:
 		handler = tint.NewHandler(
-			os.Stderr,
+			w,
 			&tint.Options{
 				Level:      opts.Level,
 				AddSource:  opts.AddSource,
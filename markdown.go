@@ -6,26 +6,47 @@ package clix
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"text/template"
 
 	"github.com/alecthomas/kong"
+	"go.yaml.in/yaml/v3"
 )
 
-// WithMarkdownPlugin adds a hidden "generate-markdown" command that allows
-// generating markdown documentation for the CLI. To make it so this command
+// WithMarkdownPlugin adds a hidden "generate-markdown" command (with a
+// "generate-docs" alias) that allows generating markdown, HTML or JSON
+// documentation for the CLI. To make it so this command
 // ignores any other required flags, it's invoked before kong applies additional
-// restrictions, which means it does not support special flags. To adjust the
-// behavior, you can use environment variables:
+// restrictions, with its own flags resolved early (see [MarkdownCommand]).
+// Flags can also be provided through environment variables:
 //
-//   - CLIX_TEMPLATE_PATH: optional path to a directory containing template files to
-//     use for the markdown.
-//   - CLIX_OUTPUT_PATH: path to write the markdown to, or '-' to write to stdout
-//     (defaults to stdout).
+//   - --templates (CLIX_TEMPLATE_PATH): optional path to a directory containing
+//     template files to use for the markdown.
+//   - --output (CLIX_OUTPUT_PATH): path to write the markdown to, or '-' to write
+//     to stdout (defaults to stdout). If the path is a directory (or ends with a
+//     path separator), one file is written per command. See
+//     [MarkdownCommand.GenerateMarkdownFiles].
+//   - --front-matter (CLIX_FRONT_MATTER): when writing one file per command,
+//     whether to include YAML front-matter in each file.
+//   - --include-hidden: include hidden commands and flags.
+//   - --inline (CLIX_INLINE): only replace the content between the
+//     "<!-- clix:begin -->" and "<!-- clix:end -->" markers in the output file
+//     (e.g. a README). See [UpdateMarkdownBlock].
+//   - --check: compare the generated documentation against the output rather
+//     than writing it, exiting with [ExitCodeDocsOutdated] if it's out of date.
+//   - --format: "markdown" (default), "html" for a single self-contained page
+//     with search (see [MarkdownCommand.GenerateHTML]), or "json" for a
+//     machine-readable description of the CLI (see [CLISpec]).
 func WithMarkdownPlugin[T any]() Option[T] {
 	var initialized atomic.Bool
 
@@ -39,72 +60,467 @@ func WithMarkdownPlugin[T any]() Option[T] {
 		cli.kongOptions = append(
 			cli.kongOptions, kong.DynamicCommand(
 				"generate-markdown",
-				"generate markdown documentation and write to stdout",
+				"generate documentation and write to stdout",
 				"",
 				cmd,
 				"hidden",
+				`aliases:"generate-docs"`,
 			),
 		)
 	}
 }
 
+// MarkdownOptions configures markdown generation. See [WithMarkdownTemplates],
+// [WithMarkdownExtra] and [CLI.GenerateMarkdownWith].
+type MarkdownOptions struct {
+	// Templates are parsed on top of the built-in templates, so individual
+	// partials can be overridden by defining a template with the same name (e.g.
+	// {{ define "helpers/flags/table" }}...{{ end }}), without having to replace
+	// all templates. The "hooks/command/before" and "hooks/command/after"
+	// templates are empty by default, and are invoked before and after each
+	// command section, with the Model, Node, Depth and Extra keys.
+	Templates fs.FS
+
+	// Extra is user-provided data, exposed to templates as .Extra.
+	Extra map[string]any
+
+	// IncludeHidden includes hidden commands and flags.
+	IncludeHidden bool
+
+	// FlagTable toggles optional columns in the flag tables.
+	FlagTable FlagTableOptions
+}
+
+// FlagTableOptions toggles optional columns in the flag tables of the generated
+// markdown. By default, only the flag (including short flags and placeholders),
+// environment variables, type and help are included.
+type FlagTableOptions struct {
+	// Default adds a "Default" column, showing placeholders rather than default
+	// values in the flag column.
+	Default bool
+
+	// Aliases adds an "Aliases" column, with flag aliases and negated forms (e.g.
+	// --no-foo).
+	Aliases bool
+
+	// Relations adds a "Relations" column, with flags which are mutually
+	// exclusive (xor), or must be provided together (and).
+	Relations bool
+
+	// Deprecated adds a "Deprecated" column, from the "deprecated" struct tag.
+	Deprecated bool
+}
+
+// WithMarkdownTemplates layers the templates in fsys on top of the built-in
+// markdown templates, for both the "generate-markdown" command (see
+// [WithMarkdownPlugin]) and [CLI.GenerateMarkdownWith]. See
+// [MarkdownOptions.Templates].
+func WithMarkdownTemplates[T any](fsys fs.FS) Option[T] {
+	return func(cli *CLI[T]) {
+		cli.markdown.Templates = fsys
+	}
+}
+
+// WithMarkdownFlagTable toggles optional columns in the flag tables of the
+// generated markdown, for the "generate-markdown" command (see
+// [WithMarkdownPlugin]) and [CLI.GenerateMarkdown]. See [FlagTableOptions].
+func WithMarkdownFlagTable[T any](opts FlagTableOptions) Option[T] {
+	return func(cli *CLI[T]) {
+		cli.markdown.FlagTable = opts
+	}
+}
+
+// WithMarkdownExtra exposes the provided data to markdown templates as .Extra,
+// for the "generate-markdown" command (see [WithMarkdownPlugin]). See
+// [MarkdownOptions.Extra].
+func WithMarkdownExtra[T any](extra map[string]any) Option[T] {
+	return func(cli *CLI[T]) {
+		cli.markdown.Extra = extra
+	}
+}
+
+// Supported formats for the --format flag of [MarkdownCommand].
+const (
+	DocsFormatMarkdown = "markdown"
+	DocsFormatHTML     = "html"
+	DocsFormatJSON     = "json"
+)
+
 type MarkdownCommand struct {
 	DisableExit bool `kong:"-"`
+
+	// Extra is user-provided data, exposed to templates as .Extra. See
+	// [MarkdownOptions.Extra].
+	Extra map[string]any `kong:"-"`
+
+	// FlagTable toggles optional columns in the flag tables. See
+	// [FlagTableOptions].
+	FlagTable FlagTableOptions `kong:"-"`
+
+	Output        string `name:"output" env:"CLIX_OUTPUT_PATH" default:"-" placeholder:"PATH" help:"path to write to, '-' for stdout, or a directory to write one file per command"`
+	Templates     string `name:"templates" env:"CLIX_TEMPLATE_PATH" placeholder:"DIR" help:"path to a directory containing templates, which override the built-in templates"`
+	Format        string `name:"format" default:"markdown" enum:"markdown,html,json" help:"output format"`
+	IncludeHidden bool   `name:"include-hidden" help:"include hidden commands and flags"`
+
+	// FrontMatter includes YAML front-matter (title, sidebar label/position and
+	// description) in each file, when generating one file per command.
+	FrontMatter bool `name:"front-matter" env:"CLIX_FRONT_MATTER" help:"include YAML front-matter when writing one file per command"`
+
+	// Inline only replaces the content between [MarkdownBlockBegin] and
+	// [MarkdownBlockEnd] in the (existing) output file. See [UpdateMarkdownBlock].
+	Inline bool `name:"inline" env:"CLIX_INLINE" help:"only replace the content between the clix:begin and clix:end markers in the output file"`
+
+	// Check compares the generated documentation against the output, rather than
+	// writing it, exiting with [ExitCodeDocsOutdated] if they differ.
+	Check bool `name:"check" help:"check if the output is up to date, rather than writing it"`
+}
+
+// ExitCodeDocsOutdated is the exit code used by the "generate-markdown" command
+// when using --check, and the generated documentation differs from the output.
+const ExitCodeDocsOutdated = 6
+
+// Markers which surround the generated documentation when using
+// [MarkdownCommand.Inline]. See [UpdateMarkdownBlock].
+const (
+	MarkdownBlockBegin = "<!-- clix:begin -->"
+	MarkdownBlockEnd   = "<!-- clix:end -->"
+)
+
+// boundMarkdownOptions returns the [MarkdownOptions] bound to the context, or
+// empty options if none are bound.
+func boundMarkdownOptions(kctx *kong.Context) *MarkdownOptions {
+	opts := &MarkdownOptions{}
+	_, _ = kctx.Call(func(bound *MarkdownOptions) {
+		if bound != nil {
+			opts = bound
+		}
+	})
+	return opts
 }
 
+// MarkdownGeneratedMarker is included in the header of generated pages (see the
+// "helpers/header" template). When writing one file per command, only pages in
+// "commands/" containing it are removed once no longer generated, so
+// hand-written pages are left alone. Custom templates overriding the header
+// should include it.
+const MarkdownGeneratedMarker = "(by clix)"
+
+// UpdateMarkdownBlock replaces the content between [MarkdownBlockBegin] and
+// [MarkdownBlockEnd] in content (e.g. an existing README) with generated, leaving
+// the markers and everything outside of them as-is. Returns an error if the
+// markers can't be found.
+func UpdateMarkdownBlock(content, generated string) (string, error) {
+	begin := strings.Index(content, MarkdownBlockBegin)
+	if begin == -1 {
+		return "", fmt.Errorf("marker %q not found", MarkdownBlockBegin)
+	}
+	begin += len(MarkdownBlockBegin)
+
+	end := strings.Index(content[begin:], MarkdownBlockEnd)
+	if end == -1 {
+		return "", fmt.Errorf("marker %q not found after %q", MarkdownBlockEnd, MarkdownBlockBegin)
+	}
+	end += begin
+
+	return content[:begin] + "\n" + strings.TrimSpace(generated) + "\n" + content[end:], nil
+}
+
+// BeforeReset runs the command before kong resets and validates the rest of the
+// model, so required flags elsewhere in the CLI don't need to be provided. As
+// flags aren't applied at this stage, they're resolved early from the command
+// line, environment variables or defaults (see [earlyFlagValue]). Options from
+// [WithMarkdownTemplates], [WithMarkdownExtra] and [WithMarkdownFlagTable] are
+// used if bound (e.g. when using [New]).
 func (m *MarkdownCommand) BeforeReset(
-	ctx *kong.Kong,
+	kctx *kong.Context,
+	path *kong.Path,
 	version *Version,
 ) error {
+	node := path.Command
+	opts := boundMarkdownOptions(kctx)
+
+	m.Output = earlyFlagValue(kctx, node, "output")
+	m.Templates = earlyFlagValue(kctx, node, "templates")
+	m.Format = earlyFlagValue(kctx, node, "format")
+	m.IncludeHidden, _ = strconv.ParseBool(earlyFlagValue(kctx, node, "include-hidden"))
+	if v, _ := strconv.ParseBool(earlyFlagValue(kctx, node, "front-matter")); v {
+		m.FrontMatter = true
+	}
+	if v, _ := strconv.ParseBool(earlyFlagValue(kctx, node, "inline")); v {
+		m.Inline = true
+	}
+	if v, _ := strconv.ParseBool(earlyFlagValue(kctx, node, "check")); v {
+		m.Check = true
+	}
+
+	dir := isDirPath(m.Output)
+	stdout := m.Output == "-" || m.Output == ""
+
+	switch {
+	case m.Format != DocsFormatMarkdown && dir:
+		return fmt.Errorf("--output: writing to a directory is only supported with --format=%s", DocsFormatMarkdown)
+	case m.Inline && (dir || stdout):
+		return errors.New("--inline: requires --output to be a file")
+	case m.Check && stdout:
+		return errors.New("--check: requires --output")
+	}
+
+	if opts.IncludeHidden {
+		m.IncludeHidden = true
+	}
+	if m.Extra == nil {
+		m.Extra = opts.Extra
+	}
+	if m.FlagTable == (FlagTableOptions{}) {
+		m.FlagTable = opts.FlagTable
+	}
+
+	if m.IncludeHidden {
+		defer unhide(kctx.Model.Node)()
+	}
+
 	var output string
+	var files map[string]string
+	var tmpl *template.Template
 	var err error
 
-	if v := os.Getenv("CLIX_TEMPLATE_PATH"); v == "" {
-		output, err = m.GenerateMarkdown(ctx.Model, nil, version)
-	} else {
-		var files []string
-		err = filepath.Walk(v, func(path string, info os.FileInfo, err error) error {
+	switch m.Format {
+	case DocsFormatMarkdown:
+		tmpl = templates
+		if opts.Templates != nil {
+			tmpl, err = layerTemplates(tmpl, opts.Templates)
+			if err != nil {
+				return err
+			}
+		}
+
+		if m.Templates != "" {
+			tmpl, err = layerTemplates(tmpl, os.DirFS(m.Templates))
 			if err != nil {
 				return err
 			}
-			if info.IsDir() {
+		}
+
+		if dir {
+			files, err = m.GenerateMarkdownFiles(kctx.Model, tmpl, version)
+			break
+		}
+
+		output, err = m.GenerateMarkdown(kctx.Model, tmpl, version)
+	case DocsFormatHTML:
+		output, err = m.GenerateHTML(kctx.Model, version)
+	case DocsFormatJSON:
+		output, err = m.GenerateJSON(kctx.Model, version)
+	default:
+		return fmt.Errorf("--format: unsupported format %q", m.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", m.Format, err)
+	}
+
+	if stdout {
+		fmt.Fprint(kctx.Stdout, output)
+	} else {
+		outDir := m.Output
+		if !dir {
+			if m.Inline {
+				b, err := os.ReadFile(m.Output)
+				if err != nil {
+					return err
+				}
+
+				output, err = UpdateMarkdownBlock(string(b), output)
+				if err != nil {
+					return fmt.Errorf("--inline: %s: %w", m.Output, err)
+				}
+			}
+
+			files = map[string]string{filepath.Base(m.Output): output}
+			outDir = filepath.Dir(m.Output)
+		}
+
+		var stale []string
+		if dir {
+			if stale, err = staleFiles(outDir, files); err != nil {
+				return err
+			}
+		}
+
+		if m.Check {
+			if outdated := append(outdatedFiles(outDir, files), stale...); len(outdated) > 0 {
+				slices.Sort(outdated)
+				fmt.Fprintf(kctx.Stderr, "generated documentation is out of date: %s\n", strings.Join(outdated, ", "))
+				kctx.Exit(ExitCodeDocsOutdated)
 				return nil
 			}
-			files = append(files, path)
-			return nil
-		})
+		} else if err = writeFiles(outDir, files, stale); err != nil {
+			return err
+		}
+	}
+
+	if !m.DisableExit {
+		kctx.Exit(0)
+	}
+	return nil
+}
+
+// layerTemplates parses all files in fsys on top of a clone of base, so templates
+// (and partials defined within them) with the same name override those in base.
+// Like [template.Template.ParseFiles], templates are named after the base name of
+// the file.
+func layerTemplates(base *template.Template, fsys fs.FS) (*template.Template, error) {
+	tmpl, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return fmt.Errorf("failed to walk template directory: %w", err)
+			return err
+		}
+		if d.IsDir() {
+			return nil
 		}
 
-		var tmpl *template.Template
-		tmpl, err = templates.ParseFiles(files...)
+		b, err := fs.ReadFile(fsys, name)
 		if err != nil {
-			return fmt.Errorf("failed to parse templates: %w", err)
+			return err
 		}
 
-		output, err = m.GenerateMarkdown(ctx.Model, tmpl, version)
+		if _, err = tmpl.New(path.Base(name)).Parse(string(b)); err != nil {
+			return fmt.Errorf("failed to parse template %q: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to generate markdown: %w", err)
+	return tmpl, nil
+}
+
+// unhide marks all hidden commands and flags under the node as visible, returning
+// a function which restores them.
+func unhide(node *kong.Node) (restore func()) {
+	var nodes []*kong.Node
+	var flags []*kong.Flag
+
+	_ = kong.Visit(node, func(n kong.Visitable, next kong.Next) error {
+		switch n := n.(type) {
+		case *kong.Node:
+			if n.Hidden {
+				n.Hidden = false
+				nodes = append(nodes, n)
+			}
+		case *kong.Flag:
+			if n.Hidden {
+				n.Hidden = false
+				flags = append(flags, n)
+			}
+		}
+		return next(nil)
+	})
+
+	return func() {
+		for _, n := range nodes {
+			n.Hidden = true
+		}
+		for _, f := range flags {
+			f.Hidden = true
+		}
 	}
+}
 
-	if v := os.Getenv("CLIX_OUTPUT_PATH"); v == "-" || v == "" {
-		fmt.Fprint(os.Stdout, output) //nolint:forbidigo
-	} else {
-		err = os.WriteFile(v, []byte(output), 0o600)
-		if err != nil {
+// isDirPath returns true if the path is an existing directory, or ends with a
+// path separator.
+func isDirPath(path string) bool {
+	if path == "" || path == "-" {
+		return false
+	}
+
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// writeFiles writes the files (keyed by slash-separated path, relative to dir)
+// to dir, creating any parent directories, and removes the stale files (see
+// [staleFiles]).
+func writeFiles(dir string, files map[string]string, stale []string) error {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
 			return err
 		}
 	}
 
-	if !m.DisableExit {
-		os.Exit(0)
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
 	}
 	return nil
 }
 
+// staleFiles returns the paths of generated markdown files (see
+// [MarkdownGeneratedMarker]) in the commands directory of dir (see
+// [MarkdownCommand.GenerateMarkdownFiles]) which aren't in files, e.g. pages of
+// removed or renamed commands.
+func staleFiles(dir string, files map[string]string) (stale []string, err error) {
+	entries, err := os.ReadDir(filepath.Join(dir, markdownCommandsDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+
+		if _, ok := files[markdownCommandsDir+"/"+entry.Name()]; ok {
+			continue
+		}
+
+		path := filepath.Join(dir, markdownCommandsDir, entry.Name())
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Contains(b, []byte(MarkdownGeneratedMarker)) {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
+
+// outdatedFiles returns the paths of the files (keyed by slash-separated path,
+// relative to dir) which don't exist in dir, or have different contents.
+func outdatedFiles(dir string, files map[string]string) (outdated []string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		b, err := os.ReadFile(path)
+		if err != nil || string(b) != content {
+			outdated = append(outdated, path)
+		}
+	}
+	slices.Sort(outdated)
+	return outdated
+}
+
 // GenerateMarkdown generates the markdown documentation for the CLI, returning the
 // markdown as a string.
 func (m *MarkdownCommand) GenerateMarkdown(
@@ -128,6 +544,7 @@ func (m *MarkdownCommand) GenerateMarkdown(
 		"AppInfo": version.AppInfo,
 		"Config":  m,
 		"Version": version,
+		"Extra":   m.Extra,
 	})
 	if err != nil {
 		return "", err
@@ -136,9 +553,194 @@ func (m *MarkdownCommand) GenerateMarkdown(
 	return buf.String(), nil
 }
 
+// Files generated by [MarkdownCommand.GenerateMarkdownFiles], in addition to one
+// file per command.
+const (
+	MarkdownIndexFile   = "index.md"      // Index page, with global flags and top-level commands.
+	MarkdownNavFile     = "nav.yml"       // MkDocs "nav" configuration.
+	MarkdownSidebarFile = "sidebars.json" // Docusaurus sidebar configuration.
+)
+
+// markdownCommandsDir is the directory (relative to the output directory) which
+// contains one file per command. See [MarkdownCommand.GenerateMarkdownFiles].
+const markdownCommandsDir = "commands"
+
+// GenerateMarkdownFiles generates the markdown documentation for the CLI, with one
+// file per command, returning the contents keyed by slash-separated file path.
+// This includes [MarkdownIndexFile], "commands/<command-path>.md" for each
+// (non-hidden) command with links between parent and child commands, and
+// navigation files for MkDocs ([MarkdownNavFile]) and Docusaurus
+// ([MarkdownSidebarFile]). See also [MarkdownCommand.FrontMatter].
+//
+// An error is returned if multiple commands result in the same file path (e.g.
+// "foo-bar" and "foo bar"). When the generate-markdown command writes to a
+// directory, generated pages in "commands/" (see [MarkdownGeneratedMarker])
+// which are no longer generated are removed (or reported, when using --check).
+func (m *MarkdownCommand) GenerateMarkdownFiles(
+	model *kong.Application,
+	tmpl *template.Template,
+	version *Version,
+) (map[string]string, error) {
+	if tmpl == nil {
+		tmpl = templates
+	}
+
+	// See [MarkdownCommand.GenerateMarkdown].
+	model.Help = version.AppInfo.Description
+
+	files := map[string]string{}
+
+	render := func(name, file string, data map[string]any) error {
+		data["Model"] = model
+		data["AppInfo"] = version.AppInfo
+		data["Config"] = m
+		data["Version"] = version
+		data["Extra"] = m.Extra
+		data["Dir"] = true
+
+		buf := bytes.NewBuffer(nil)
+		if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+			return err
+		}
+		files[file] = buf.String()
+		return nil
+	}
+
+	if err := render("index.gotmpl", MarkdownIndexFile, map[string]any{
+		"Children": unhiddenCommands(model.Node),
+	}); err != nil {
+		return nil, err
+	}
+
+	nav := []any{map[string]any{model.Name: MarkdownIndexFile}}
+	sidebar := []any{"index"}
+
+	pages := map[string]string{} // File path -> command path.
+
+	var walk func(node *kong.Node, parents []*kong.Node) (navItems, sidebarItems []any, err error)
+	walk = func(node *kong.Node, parents []*kong.Node) (navItems, sidebarItems []any, err error) {
+		for i, child := range unhiddenCommands(node) {
+			file := markdownCommandsDir + "/" + slugify(child.Path()) + ".md"
+			title := model.Name + " " + child.Path()
+
+			if other, ok := pages[file]; ok {
+				return nil, nil, fmt.Errorf("commands %q and %q both generate %s", other, child.Path(), file)
+			}
+			pages[file] = child.Path()
+
+			position := i + 1
+			if len(parents) == 0 {
+				position++ // Index is always first.
+			}
+
+			err = render("page.gotmpl", file, map[string]any{
+				"Node":     child,
+				"Parents":  parents,
+				"Children": unhiddenCommands(child),
+				"Position": position,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			childNav, childSidebar, err := walk(child, append(slices.Clone(parents), child))
+			if err != nil {
+				return nil, nil, err
+			}
+
+			docID := strings.TrimSuffix(file, ".md")
+
+			if len(childNav) == 0 {
+				navItems = append(navItems, map[string]any{title: file})
+				sidebarItems = append(sidebarItems, docID)
+				continue
+			}
+
+			navItems = append(navItems, map[string]any{title: append([]any{file}, childNav...)})
+			category := sidebarCategory{Type: "category", Label: child.Name, Items: childSidebar}
+			category.Link.Type = "doc"
+			category.Link.ID = docID
+			sidebarItems = append(sidebarItems, category)
+		}
+		return navItems, sidebarItems, nil
+	}
+
+	navItems, sidebarItems, err := walk(model.Node, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(map[string]any{"nav": append(nav, navItems...)}); err != nil {
+		return nil, err
+	}
+	files[MarkdownNavFile] = buf.String()
+
+	b, err := json.MarshalIndent(map[string]any{"cli": append(sidebar, sidebarItems...)}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	files[MarkdownSidebarFile] = string(b) + "\n"
+
+	return files, nil
+}
+
+// sidebarCategory is a Docusaurus sidebar category, linked to a doc.
+type sidebarCategory struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Link  struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"link"`
+	Items []any `json:"items"`
+}
+
+// unhiddenCommands returns the non-hidden command children of the node.
+func unhiddenCommands(node *kong.Node) []*kong.Node {
+	var results []*kong.Node
+	for _, child := range node.Children {
+		if child.Type == kong.CommandNode && !child.Hidden {
+			results = append(results, child)
+		}
+	}
+	return results
+}
+
+// GenerateMarkdown generates the markdown documentation for the CLI, using the
+// options provided through [WithMarkdownTemplates] and [WithMarkdownExtra]. See
+// also [CLI.GenerateMarkdownWith].
 func (cli *CLI[T]) GenerateMarkdown() (string, error) {
+	return cli.GenerateMarkdownWith(*cli.markdown)
+}
+
+// GenerateMarkdownWith generates the markdown documentation for the CLI, using the
+// provided options rather than those provided through [WithMarkdownTemplates] and
+// [WithMarkdownExtra].
+func (cli *CLI[T]) GenerateMarkdownWith(opts MarkdownOptions) (string, error) {
 	if cli.Context == nil {
 		return "", errors.New("context not initialized, must parse first")
 	}
-	return (&MarkdownCommand{}).GenerateMarkdown(cli.Context.Model, nil, cli.version)
+
+	tmpl := templates
+	if opts.Templates != nil {
+		var err error
+		tmpl, err = layerTemplates(tmpl, opts.Templates)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if opts.IncludeHidden {
+		defer unhide(cli.Context.Model.Node)()
+	}
+
+	cmd := &MarkdownCommand{
+		Extra:         opts.Extra,
+		IncludeHidden: opts.IncludeHidden,
+		FlagTable:     opts.FlagTable,
+	}
+	return cmd.GenerateMarkdown(cli.Context.Model, tmpl, cli.version)
 }
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/alecthomas/kong"
 )
@@ -76,3 +77,401 @@ func TestWithMarkdownPlugin(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateMarkdownFiles(t *testing.T) {
+	type Flags struct {
+		Foo struct {
+			Bar struct {
+				Baz string `name:"baz" help:"baz flag"`
+			} `cmd:"" help:"bar command"`
+		} `cmd:"" help:"foo command"`
+		Hidden struct{} `cmd:"" hidden:""`
+	}
+
+	dir := t.TempDir()
+	t.Setenv("CLIX_OUTPUT_PATH", dir)
+	t.Setenv("CLIX_FRONT_MATTER", "true")
+
+	// Generated pages of removed commands should be pruned, leaving other files
+	// (including hand-written pages) alone.
+	if err := os.MkdirAll(filepath.Join(dir, "commands"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	stale := "<!-- auto-generated " + MarkdownGeneratedMarker + " -->\nstale"
+	for name, content := range map[string]string{
+		"removed.md": stale,
+		"notes.txt":  "stale",
+		"guide.md":   "hand-written",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "commands", name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	run := func(args ...string) (code int) {
+		os.Args = append([]string{"clix", "generate-markdown"}, args...)
+		code = -1
+
+		New(
+			WithKongOptions[Flags](
+				kong.DynamicCommand(
+					"generate-markdown",
+					"generate markdown documentation and write to stdout",
+					"",
+					&MarkdownCommand{DisableExit: true},
+					"hidden",
+				),
+				kong.Writers(&strings.Builder{}, &strings.Builder{}),
+				kong.Exit(func(c int) {
+					if code == -1 {
+						code = c
+					}
+				}),
+			),
+		)
+		return code
+	}
+
+	if code := run(); code != -1 {
+		t.Fatalf("expected no exit when generating docs, got %d", code)
+	}
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "commands", "hidden.md")); err == nil {
+		t.Fatal("expected no page for hidden command")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "commands", "removed.md")); err == nil {
+		t.Fatal("expected page of removed command to be pruned")
+	}
+
+	if read("commands/notes.txt") != "stale" {
+		t.Fatal("expected non-markdown files to be left alone")
+	}
+
+	if read("commands/guide.md") != "hand-written" {
+		t.Fatal("expected hand-written pages to be left alone")
+	}
+
+	if code := run("--check"); code != -1 {
+		t.Fatalf("expected no exit for up to date docs, got %d", code)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "commands", "removed.md"), []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run("--check"); code != ExitCodeDocsOutdated {
+		t.Fatalf("expected exit code %d for stale page, got %d", ExitCodeDocsOutdated, code)
+	}
+
+	expected := map[string][]string{
+		MarkdownIndexFile: {
+			"---\ntitle: \"clix\"",
+			"## Global Flags",
+			"[`clix foo`](commands/foo.md): foo command",
+		},
+		"commands/foo.md": {
+			"sidebar_position: 2",
+			"[`clix`](../index.md) / `foo`",
+			"[`clix foo bar`](foo-bar.md): bar command",
+		},
+		"commands/foo-bar.md": {
+			"sidebar_label: \"bar\"",
+			"[`clix`](../index.md) / [`foo`](foo.md) / `bar`",
+			"--baz=STRING",
+		},
+		MarkdownNavFile: {
+			"- clix: index.md",
+			"- clix foo:\n      - commands/foo.md\n      - clix foo bar: commands/foo-bar.md",
+		},
+		MarkdownSidebarFile: {
+			`"id": "commands/foo"`,
+			`"commands/foo-bar"`,
+		},
+	}
+
+	for file, want := range expected {
+		data := read(file)
+		for _, e := range want {
+			if !strings.Contains(data, e) {
+				t.Fatalf("expected %q to be in %s, got:\n%s", e, file, data)
+			}
+		}
+	}
+}
+
+func TestGenerateMarkdownFilesDuplicate(t *testing.T) {
+	type Flags struct {
+		FooBar struct{} `cmd:"" name:"foo-bar" help:"foo-bar command"`
+		Foo    struct {
+			Bar struct{} `cmd:"" help:"bar command"`
+		} `cmd:"" help:"foo command"`
+	}
+
+	k, err := kong.New(&Flags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = (&MarkdownCommand{}).GenerateMarkdownFiles(k.Model, nil, &Version{AppInfo: &AppInfo{}})
+	if err == nil || !strings.Contains(err.Error(), "commands/foo-bar.md") {
+		t.Fatalf("expected error for duplicate command pages, got %v", err)
+	}
+}
+
+func TestMarkdownCommandKong(t *testing.T) {
+	// The command can be used with kong directly, without binding options.
+	var cli struct {
+		Docs MarkdownCommand `cmd:""`
+	}
+
+	var buf strings.Builder
+	k, err := kong.New(
+		&cli,
+		kong.Name("testapp"),
+		kong.Bind(&Version{AppInfo: &AppInfo{Name: "testapp"}}),
+		kong.Writers(&buf, &buf),
+		kong.Exit(func(int) {}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = k.Parse([]string{"docs", "--format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `"name": "testapp"`) {
+		t.Fatalf("expected generated docs, got:\n%s", buf.String())
+	}
+}
+
+func TestMarkdownCommandFlags(t *testing.T) {
+	type Flags struct {
+		Required string `name:"required" required:""`
+		Secret   string `name:"secret" hidden:"" help:"hidden flag"`
+
+		Internal struct{} `cmd:"" hidden:"" help:"internal command"`
+	}
+
+	fn := filepath.Join(t.TempDir(), "usage.md")
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"clix", "generate-markdown", "--output", fn, "--include-hidden"}
+
+	New(
+		WithKongOptions[Flags](
+			kong.DynamicCommand(
+				"generate-markdown",
+				"generate markdown documentation and write to stdout",
+				"",
+				&MarkdownCommand{DisableExit: true},
+				"hidden",
+			),
+			kong.Writers(&strings.Builder{}, &strings.Builder{}),
+			kong.Exit(func(int) {}),
+		),
+	)
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []string{"--secret=STRING", "clix internal", "--required=STRING"} {
+		if !strings.Contains(string(b), e) {
+			t.Fatalf("expected %q to be in generated markdown, got:\n%s", e, b)
+		}
+	}
+}
+
+func TestGenerateMarkdownWith(t *testing.T) {
+	type Flags struct {
+		Foo struct {
+			Bar string `name:"bar" help:"bar flag"`
+		} `cmd:"" help:"foo command"`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"clix", "foo"}
+
+	partials := fstest.MapFS{
+		"flags.gotmpl": {Data: []byte(
+			`{{- define "helpers/flags/table" }}{{ range .Flags }}- custom {{ .Name }}{{ "\n" }}{{ end }}{{ end }}`,
+		)},
+		"hooks.gotmpl": {Data: []byte(
+			`{{- define "hooks/command/after" }}{{ "\n" }}Owned by {{ .Extra.team }} ({{ .Node.Name }}).{{ end }}`,
+		)},
+	}
+
+	cli := New(
+		WithKongOptions[Flags](kong.Exit(func(int) {})),
+		WithMarkdownTemplates[Flags](partials),
+		WithMarkdownExtra[Flags](map[string]any{"team": "platform"}),
+	)
+
+	out, err := cli.GenerateMarkdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []string{"- custom bar", "Owned by platform (foo).", "## Commands"} {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q to be in generated markdown, got:\n%s", e, out)
+		}
+	}
+
+	if strings.Contains(out, "| Flag(s)") {
+		t.Fatalf("expected flags table partial to be overridden, got:\n%s", out)
+	}
+
+	// Options provided directly shouldn't include those from the CLI options.
+	out, err = cli.GenerateMarkdownWith(MarkdownOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out, "Owned by") || !strings.Contains(out, "| Flag(s)") {
+		t.Fatalf("expected built-in templates, got:\n%s", out)
+	}
+}
+
+func TestMarkdownFlagTable(t *testing.T) {
+	type Flags struct {
+		Get struct {
+			Format  string `name:"format" aliases:"fmt" default:"text" placeholder:"FORMAT" xor:"output" help:"output format"`
+			JSON    bool   `name:"json" xor:"output" help:"output as JSON"`
+			Color   bool   `name:"color" negatable:"" help:"colorize output"`
+			Verbose int    `name:"verbose" short:"v" type:"counter" help:"verbosity"`
+			Old     string `name:"old" deprecated:"use --format instead" help:"old flag"`
+
+			Name  string `arg:"" help:"name of the item"`
+			Extra string `arg:"" optional:"" default:"x" enum:"x,y"`
+		} `cmd:""`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"clix", "get", "foo"}
+
+	cli := New(WithKongOptions[Flags](kong.Exit(func(int) {})))
+
+	out, err := cli.GenerateMarkdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "| Flag(s) ") || strings.Contains(out, "| Aliases") {
+		t.Fatalf("expected optional columns to be disabled by default, got:\n%s", out)
+	}
+
+	out, err = cli.GenerateMarkdownWith(MarkdownOptions{
+		FlagTable: FlagTableOptions{Default: true, Aliases: true, Relations: true, Deprecated: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"| Flag(s) ",
+		"| Aliases ",
+		"| Default ",
+		"| Relations ",
+		"| Deprecated ",
+		"`--format=FORMAT`",
+		"`--fmt`",
+		"`--no-color`",
+		"**xor**: `output`",
+		"**counter**",
+		"use \\-\\-format instead",
+		"#### Arguments",
+		"| `<name>`  ",
+		"`<extra>`<br>**default**: `x`<br><br>**options**:<br><ul><li>`x`</li><li>`y`</li></ul>",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q to be in generated markdown, got:\n%s", e, out)
+		}
+	}
+}
+
+func TestMarkdownInline(t *testing.T) {
+	type Flags struct {
+		Foo string `name:"foo" help:"foo flag"`
+	}
+
+	fn := filepath.Join(t.TempDir(), "README.md")
+	readme := "# my-app\n\nintro\n\n" + MarkdownBlockBegin + "\nstale\n" + MarkdownBlockEnd + "\n\noutro\n"
+	if err := os.WriteFile(fn, []byte(readme), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	run := func(args ...string) (code int) {
+		os.Args = append([]string{"clix", "generate-markdown", "--output", fn, "--inline"}, args...)
+		code = -1
+
+		New(
+			WithKongOptions[Flags](
+				kong.DynamicCommand(
+					"generate-markdown",
+					"generate documentation and write to stdout",
+					"",
+					&MarkdownCommand{DisableExit: true},
+					"hidden",
+				),
+				kong.Writers(&strings.Builder{}, &strings.Builder{}),
+				kong.Exit(func(c int) {
+					if code == -1 {
+						code = c
+					}
+				}),
+			),
+		)
+		return code
+	}
+
+	if code := run("--check"); code != ExitCodeDocsOutdated {
+		t.Fatalf("expected exit code %d for stale docs, got %d", ExitCodeDocsOutdated, code)
+	}
+
+	if code := run(); code != -1 {
+		t.Fatalf("expected no exit when updating docs, got %d", code)
+	}
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := string(b)
+	if !strings.HasPrefix(data, "# my-app\n\nintro\n\n"+MarkdownBlockBegin+"\n<!--") ||
+		!strings.HasSuffix(data, "|\n"+MarkdownBlockEnd+"\n\noutro\n") ||
+		!strings.Contains(data, "--foo=STRING") || strings.Contains(data, "stale") {
+		t.Fatalf("unexpected inline update:\n%s", data)
+	}
+
+	if code := run("--check"); code != -1 {
+		t.Fatalf("expected no exit for up to date docs, got %d", code)
+	}
+
+	if _, err = UpdateMarkdownBlock("no markers", "foo"); err == nil {
+		t.Fatal("expected error when markers are missing")
+	}
+}
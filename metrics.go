@@ -0,0 +1,118 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+var metricLabelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// VersionHandler returns an [http.Handler] which serves the non-sensitive version
+// information (see [NonSensitiveVersion]) as JSON, e.g. for a "/version" endpoint.
+func (cli *CLI[T]) VersionHandler() http.Handler {
+	return NewVersionHandler(cli.version)
+}
+
+// BuildInfoHandler returns an [http.Handler] which serves the build_info metric
+// in the OpenMetrics text format. See [Version.WriteBuildInfoMetric].
+func (cli *CLI[T]) BuildInfoHandler() http.Handler {
+	return NewBuildInfoHandler(cli.version)
+}
+
+// NewVersionHandler returns an [http.Handler] which serves the non-sensitive
+// version information (see [NonSensitiveVersion]) as JSON.
+func NewVersionHandler(v *Version) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		b, err := json.Marshal(v.NonSensitive())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(append(b, '\n'))
+	})
+}
+
+// NewBuildInfoHandler returns an [http.Handler] which serves the build_info
+// metric in the OpenMetrics text format. See [Version.WriteBuildInfoMetric].
+func NewBuildInfoHandler(v *Version) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		buf := &bytes.Buffer{}
+		if err := v.WriteBuildInfoMetric(buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		buf.WriteString("# EOF\n")
+
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// MetricName returns the application name, sanitized for use as a metric name
+// prefix (e.g. "github.com/example/my-app" becomes "my_app").
+func (v *Version) MetricName() string {
+	name := []byte(filepath.Base(v.AppInfo.Name))
+	for i, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			name[i] = '_'
+		}
+	}
+
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = append([]byte{'_'}, name...)
+	}
+
+	return string(name)
+}
+
+// WriteBuildInfoMetric writes a "<app>_build_info" gauge metric family (with
+// version, commit, goversion, os and arch labels, and a constant value of 1) to
+// w, in the OpenMetrics (and Prometheus) text format, without requiring a
+// Prometheus client dependency. The "# EOF" marker is not written, so the output
+// can be combined with other metrics.
+func (v *Version) WriteBuildInfoMetric(w io.Writer) error {
+	name := v.MetricName() + "_build_info"
+
+	labels := []string{
+		"version", v.AppInfo.Version,
+		"commit", v.AppInfo.Commit,
+		"goversion", v.GoVersion,
+		"os", v.OS,
+		"arch", v.Arch,
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], metricLabelReplacer.Replace(labels[i+1])))
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"# HELP %[1]s Build information about the application.\n# TYPE %[1]s gauge\n%[1]s{%[2]s} 1\n",
+		name, strings.Join(pairs, ","),
+	)
+	return err
+}
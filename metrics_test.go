@@ -0,0 +1,76 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testMetricsVersion() *Version {
+	return &Version{
+		AppInfo:   &AppInfo{Name: "github.com/example/my-app", Version: "v1.2.3", Commit: `abc"123`},
+		Settings:  []BuildSetting{{Key: "-ldflags", Value: "-X secret=value"}},
+		GoVersion: "go1.25.0",
+		OS:        "linux",
+		Arch:      "amd64",
+	}
+}
+
+func TestWriteBuildInfoMetric(t *testing.T) {
+	var buf strings.Builder
+	if err := testMetricsVersion().WriteBuildInfoMetric(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# HELP my_app_build_info Build information about the application.\n" +
+		"# TYPE my_app_build_info gauge\n" +
+		`my_app_build_info{version="v1.2.3",commit="abc\"123",goversion="go1.25.0",os="linux",arch="amd64"} 1` + "\n"
+
+	if buf.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	if name := (&Version{AppInfo: &AppInfo{Name: "1app"}}).MetricName(); name != "_1app" {
+		t.Fatalf("expected metric name to not start with a digit, got %q", name)
+	}
+}
+
+func TestVersionHandlers(t *testing.T) {
+	version := testMetricsVersion()
+
+	rec := httptest.NewRecorder()
+	NewVersionHandler(version).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", http.NoBody))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("expected JSON content type, got %q", ct)
+	}
+
+	var out NonSensitiveVersion
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.AppInfo.Version != "v1.2.3" {
+		t.Fatalf("expected version v1.2.3, got %q", out.AppInfo.Version)
+	}
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("expected build settings to be excluded, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	NewVersionHandler(version).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/version", http.NoBody))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	NewBuildInfoHandler(version).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	if !strings.HasSuffix(rec.Body.String(), "} 1\n# EOF\n") {
+		t.Fatalf("expected metric followed by EOF marker, got:\n%s", rec.Body.String())
+	}
+}
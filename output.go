@@ -0,0 +1,485 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kong"
+	"go.yaml.in/yaml/v3"
+)
+
+// Supported output formats, used with [WithOutputPlugin].
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	OutputTemplate = "template"
+)
+
+// minTableColumnWidth is the minimum width a table column will be truncated to
+// when the table doesn't fit within the terminal width.
+const minTableColumnWidth = 5
+
+// WithOutputPlugin adds the output plugin to the CLI. This includes the
+// --output/-o and --no-headers flags, which control how values passed to
+// [CLI.Render] are rendered. Supported formats:
+//
+//   - table: aligned columns, truncated to fit the terminal width (default).
+//   - json: indented JSON.
+//   - yaml: YAML, using the same field names as JSON.
+//   - csv: comma-separated values.
+//   - template=<go-template>: a [text/template] executed against each record
+//     (e.g. -o template='{{.Name}}'), with the same helper functions as the
+//     markdown templates.
+//
+// For table and csv output, struct fields are used as columns. Column names can
+// be changed with the "output" struct tag (falling back to the "json" tag), and
+// fields can be excluded with `output:"-"`.
+//
+// The "generate-markdown" command (see [WithMarkdownPlugin]) has its own
+// --output flag, which takes precedence when provided after the command name.
+func WithOutputPlugin[T any]() Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+
+		var flags struct {
+			Output *OutputPlugin `embed:"" group:"Output flags"`
+		}
+
+		cli.Plugins = append(cli.Plugins, &flags)
+		cli.kongOptions = append(cli.kongOptions, kong.WithAfterApply(func() error {
+			if initialized.Swap(true) {
+				return nil
+			}
+
+			// Kong allocates embedded structs while building the model, so only
+			// reference them once parsed.
+			cli.output = flags.Output
+			return flags.Output.Validate()
+		}))
+	}
+}
+
+// Render renders the provided value (usually a struct, or slice of structs) to
+// stdout (see [kong.Writers]), using the format provided through the --output flag. If the output
+// plugin is not enabled, table output is used. See [WithOutputPlugin].
+func (cli *CLI[T]) Render(v any) error {
+	o := cli.output
+	if o == nil {
+		o = &OutputPlugin{Output: OutputTable}
+	}
+
+	var w io.Writer = os.Stdout
+	if cli.Context != nil {
+		w = cli.Context.Stdout
+	}
+
+	o.term = cli.Term()
+	return o.Render(w, v)
+}
+
+// OutputPlugin are the flags that define how values passed to [CLI.Render] are
+// rendered.
+type OutputPlugin struct {
+	// Output is the output format, must be one of table|json|yaml|csv|template=<go-template>.
+	Output string `short:"o" name:"output" default:"table" help:"output format (table, json, yaml, csv, template=<go-template>)"`
+
+	// NoHeaders disables headers in table and csv output.
+	NoHeaders bool `name:"no-headers" help:"disables headers in table and csv output"`
+
+	term *Terminal
+}
+
+// Format returns the output format, and the template if the format is
+// "template".
+func (o *OutputPlugin) Format() (format, tmpl string) {
+	format, tmpl, _ = strings.Cut(o.Output, "=")
+	return strings.ToLower(strings.TrimSpace(format)), tmpl
+}
+
+// Validate validates the configured output format.
+func (o *OutputPlugin) Validate() error {
+	format, tmpl := o.Format()
+
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML, OutputCSV:
+		return nil
+	case OutputTemplate:
+		if tmpl == "" {
+			return fmt.Errorf("--output: template format requires a template, e.g. %s={{.Name}}", OutputTemplate)
+		}
+		_, err := template.New("output").Funcs(tmplFuncMap).Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("--output: invalid template: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf(
+			"--output: unknown format %q, must be one of: %s, %s, %s, %s, %s=<go-template>",
+			format, OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTemplate,
+		)
+	}
+}
+
+// Render renders the provided value to w, using the configured output format.
+func (o *OutputPlugin) Render(w io.Writer, v any) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	format, tmpl := o.Format()
+
+	switch format {
+	case OutputJSON:
+		return renderJSON(w, v)
+	case OutputYAML:
+		return renderYAML(w, v)
+	case OutputCSV:
+		headers, rows := tabulate(v)
+		cw := csv.NewWriter(w)
+		if !o.NoHeaders {
+			if err := cw.Write(headers); err != nil {
+				return err
+			}
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	case OutputTemplate:
+		return renderTemplate(w, tmpl, v)
+	default:
+		var width int
+		if o.term != nil && o.term.StdoutTTY() {
+			width = o.term.Width()
+		}
+
+		headers, rows := tabulate(v)
+		if o.NoHeaders {
+			headers = nil
+		}
+		return renderTable(w, width, headers, rows)
+	}
+}
+
+func renderJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(v)
+}
+
+// renderYAML renders the value as YAML, by first encoding it as JSON, so the
+// field names and ordering match the JSON output.
+func renderYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err = yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err = enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resetYAMLStyle resets the style of all nodes, as JSON is parsed using the flow
+// style. The encoder will still quote any strings which need it.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// renderTemplate executes the template against each record (or the value itself,
+// if it isn't a slice), with each execution followed by a newline.
+func renderTemplate(w io.Writer, tmpl string, v any) error {
+	t, err := template.New("output").Funcs(tmplFuncMap).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	for _, record := range records(v) {
+		if err = t.Execute(buf, record.Interface()); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// renderTable renders an aligned table. If width is > 0, columns are truncated
+// (largest first) to try and fit within the width.
+func renderTable(w io.Writer, width int, headers []string, rows [][]string) error {
+	ncols := len(headers)
+	for _, row := range rows {
+		ncols = max(ncols, len(row))
+	}
+
+	sizes := make([]int, ncols)
+	for _, row := range append([][]string{headers}, rows...) {
+		for i, cell := range row {
+			sizes[i] = max(sizes[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	if width > 0 {
+		const padding = 2
+		total := func() (n int) {
+			for _, s := range sizes {
+				n += s + padding
+			}
+			return n - padding
+		}
+
+		for total() > width {
+			widest := 0
+			for i := range sizes {
+				if sizes[i] > sizes[widest] {
+					widest = i
+				}
+			}
+			if sizes[widest] <= minTableColumnWidth {
+				break
+			}
+			sizes[widest]--
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	writeRow := func(row []string) {
+		for i := range ncols {
+			var cell string
+			if i < len(row) {
+				cell = truncate(row[i], sizes[i])
+			}
+
+			buf.WriteString(cell)
+			if i < ncols-1 {
+				buf.WriteString(strings.Repeat(" ", sizes[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(headers) > 0 {
+		writeRow(headers)
+	}
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func truncate(s string, size int) string {
+	if utf8.RuneCountInString(s) <= size {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:size-1]) + "…"
+}
+
+// records returns the value as a list of records. Slices and arrays return each
+// element, other values are returned as a single record. Nil pointers are
+// skipped.
+func records(v any) []reflect.Value {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return nil
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []reflect.Value{rv}
+	}
+
+	out := make([]reflect.Value, 0, rv.Len())
+	for i := range rv.Len() {
+		el := rv.Index(i)
+		for el.Kind() == reflect.Pointer || el.Kind() == reflect.Interface {
+			if el.IsNil() {
+				break
+			}
+			el = el.Elem()
+		}
+		if (el.Kind() == reflect.Pointer || el.Kind() == reflect.Interface) && el.IsNil() {
+			continue
+		}
+		out = append(out, el)
+	}
+	return out
+}
+
+// tabulate converts the value into headers and rows. Structs use their exported
+// fields as columns, maps use their (sorted) keys, and other values use a single
+// "VALUE" column.
+func tabulate(v any) (headers []string, rows [][]string) {
+	recs := records(v)
+	if len(recs) == 0 {
+		return nil, nil
+	}
+
+	switch recs[0].Kind() { //nolint:exhaustive
+	case reflect.Struct:
+		fields := outputFields(recs[0].Type())
+		for _, f := range fields {
+			headers = append(headers, strings.ToUpper(f.name))
+		}
+
+		for _, rec := range recs {
+			row := make([]string, len(fields))
+			if rec.Type() == recs[0].Type() {
+				for i, f := range fields {
+					// Fields of nil embedded pointers are left empty.
+					if fv, err := rec.FieldByIndexErr(f.index); err == nil {
+						row[i] = formatCell(fv)
+					}
+				}
+			}
+			rows = append(rows, row)
+		}
+	case reflect.Map:
+		var keys []string
+		for _, rec := range recs {
+			for _, k := range rec.MapKeys() {
+				key := fmt.Sprint(k.Interface())
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			headers = append(headers, strings.ToUpper(k))
+		}
+
+		for _, rec := range recs {
+			values := map[string]reflect.Value{}
+			iter := rec.MapRange()
+			for iter.Next() {
+				values[fmt.Sprint(iter.Key().Interface())] = iter.Value()
+			}
+
+			row := make([]string, len(keys))
+			for i, k := range keys {
+				if mv, ok := values[k]; ok {
+					row[i] = formatCell(mv)
+				}
+			}
+			rows = append(rows, row)
+		}
+	default:
+		headers = []string{"VALUE"}
+		for _, rec := range recs {
+			rows = append(rows, []string{formatCell(rec)})
+		}
+	}
+
+	return headers, rows
+}
+
+type outputField struct {
+	name  string
+	index []int
+}
+
+// outputFields returns the exported fields of the struct type, including fields
+// of exported embedded structs.
+func outputFields(typ reflect.Type) (fields []outputField) {
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous || !isExportedPath(typ, f.Index) {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("output"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		} else if tag, ok := f.Tag.Lookup("json"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		fields = append(fields, outputField{name: name, index: f.Index})
+	}
+	return fields
+}
+
+// isExportedPath returns false if the field index goes through an unexported
+// embedded struct, as its fields can't be accessed through reflection.
+func isExportedPath(typ reflect.Type, index []int) bool {
+	for i := range len(index) - 1 {
+		f := typ.FieldByIndex(index[:i+1])
+		if !f.IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+func formatCell(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range v.Len() {
+			items[i] = formatCell(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	default:
+		return strings.ReplaceAll(fmt.Sprint(v.Interface()), "\n", " ")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestOutputPluginRender(t *testing.T) {
+	type record struct {
+		Name    string `json:"name"`
+		Count   int    `json:"count"`
+		Ignored string `json:"-"`
+		Note    string `json:"note,omitempty" output:"description"`
+	}
+
+	records := []record{
+		{Name: "foo", Count: 1, Note: "first"},
+		{Name: "barbaz", Count: 22, Note: "second, with comma"},
+	}
+
+	tests := []struct {
+		output    string
+		noHeaders bool
+		want      string
+	}{
+		{
+			output: "table",
+			want:   "NAME    COUNT  DESCRIPTION\nfoo     1      first\nbarbaz  22     second, with comma\n",
+		},
+		{
+			output:    "table",
+			noHeaders: true,
+			want:      "foo     1   first\nbarbaz  22  second, with comma\n",
+		},
+		{
+			output: "csv",
+			want:   "NAME,COUNT,DESCRIPTION\nfoo,1,first\nbarbaz,22,\"second, with comma\"\n",
+		},
+		{
+			output: "json",
+			want:   "[\n    {\n        \"name\": \"foo\",\n        \"count\": 1,\n        \"note\": \"first\"\n    },\n    {\n        \"name\": \"barbaz\",\n        \"count\": 22,\n        \"note\": \"second, with comma\"\n    }\n]\n",
+		},
+		{
+			output: "yaml",
+			want:   "- name: foo\n  count: 1\n  note: first\n- name: barbaz\n  count: 22\n  note: second, with comma\n",
+		},
+		{
+			output: "template={{ .Name | upper }}={{ .Count }}",
+			want:   "FOO=1\nBARBAZ=22\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			var buf strings.Builder
+
+			o := &OutputPlugin{Output: tt.output, NoHeaders: tt.noHeaders}
+			if err := o.Render(&buf, records); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.want {
+				t.Fatalf("unexpected output:\n%q\nwant:\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+type testOutputMeta struct {
+	ID string
+}
+
+type TestOutputOwner struct {
+	Owner string
+}
+
+func TestRenderEmbedded(t *testing.T) {
+	type record struct {
+		testOutputMeta
+		*TestOutputOwner
+		Name string
+	}
+
+	records := []record{
+		{testOutputMeta: testOutputMeta{ID: "1"}, TestOutputOwner: &TestOutputOwner{Owner: "bob"}, Name: "foo"},
+		{testOutputMeta: testOutputMeta{ID: "2"}, Name: "bar"},
+	}
+
+	var buf strings.Builder
+	o := &OutputPlugin{Output: OutputCSV}
+	if err := o.Render(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields of unexported embedded structs are skipped, and fields of nil
+	// embedded pointers are empty.
+	if want := "OWNER,NAME\nbob,foo\n,bar\n"; buf.String() != want {
+		t.Fatalf("unexpected output:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestWithOutputPlugin(t *testing.T) {
+	type Flags struct{}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	fn := filepath.Join(t.TempDir(), "README.md")
+	os.Args = []string{"testapp", "-o", "json", "generate-markdown", "--output", fn}
+
+	var buf strings.Builder
+	code := -1
+
+	// The markdown plugin also has an --output flag, which is used when provided
+	// after the command.
+	cli := New(
+		WithKongOptions[Flags](
+			kong.Writers(&buf, &buf),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithOutputPlugin[Flags](),
+		WithMarkdownPlugin[Flags](),
+	)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d:\n%s", code, buf.String())
+	}
+
+	if _, err := os.Stat(fn); err != nil {
+		t.Fatalf("expected markdown to be written to --output: %v", err)
+	}
+
+	if cli.output == nil {
+		t.Fatal("expected output plugin to be initialized")
+	}
+
+	os.Args = []string{"testapp", "-o", "json"}
+
+	cli = New(
+		WithKongOptions[Flags](kong.Exit(func(int) { t.Fatal("unexpected exit") })),
+		WithOutputPlugin[Flags](),
+		WithMarkdownPlugin[Flags](),
+	)
+
+	if cli.output == nil || cli.output.Output != OutputJSON {
+		t.Fatalf("expected output format to be json, got %+v", cli.output)
+	}
+}
+
+func TestRenderWriters(t *testing.T) {
+	type Flags struct{}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "-o", "json"}
+
+	var buf strings.Builder
+	cli := New(
+		WithKongOptions[Flags](kong.Writers(&buf, &buf)),
+		WithOutputPlugin[Flags](),
+	)
+
+	if err := cli.Render(map[string]int{"count": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "{\n    \"count\": 1\n}\n"; buf.String() != want {
+		t.Fatalf("expected output to be written to the kong writers, got %q", buf.String())
+	}
+}
+
+func TestOutputPluginValidate(t *testing.T) {
+	for _, output := range []string{"xml", "template", "template={{ .Name"} {
+		if err := (&OutputPlugin{Output: output}).Validate(); err == nil {
+			t.Fatalf("expected error for output %q", output)
+		}
+	}
+}
+
+func TestRenderTableTruncate(t *testing.T) {
+	var buf strings.Builder
+
+	err := renderTable(&buf, 20, []string{"NAME", "VALUE"}, [][]string{
+		{"foo", "a very long value that should be truncated"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if n := len([]rune(line)); n > 20 {
+			t.Fatalf("expected line to be at most 20 characters, got %d: %q", n, line)
+		}
+	}
+
+	if !strings.Contains(buf.String(), "…") {
+		t.Fatalf("expected truncated output, got:\n%s", buf.String())
+	}
+}
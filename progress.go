@@ -0,0 +1,293 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kong"
+)
+
+const (
+	// progressRenderInterval is how often progress is redrawn on a TTY.
+	progressRenderInterval = 100 * time.Millisecond
+
+	// progressLogInterval is how often progress is logged, when not on a TTY.
+	progressLogInterval = 5 * time.Second
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// stderr is used by the logging plugin and progress renderer, so log entries
+// don't corrupt any progress currently being drawn.
+var stderr = &progressWriter{w: os.Stderr}
+
+// errWriter returns the writer used for log entries, progress and notices. This
+// is the package stderr writer, unless kong was configured with a different
+// stderr writer (see [kong.Writers]), e.g. when capturing output in tests.
+func (cli *CLI[T]) errWriter(kctx *kong.Context) *progressWriter {
+	if kctx == nil || kctx.Stderr == nil || kctx.Stderr == os.Stderr {
+		return stderr
+	}
+	if cli.stderr == nil || cli.stderr.w != kctx.Stderr {
+		cli.stderr = &progressWriter{w: kctx.Stderr}
+	}
+	return cli.stderr
+}
+
+// progressWriter is a writer which coordinates writes with the currently drawn
+// progress line. Before each write, the progress line is cleared, and then
+// redrawn after the write.
+type progressWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	line string // Currently drawn progress line, if any.
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.line == "" {
+		return pw.w.Write(b)
+	}
+
+	_, _ = io.WriteString(pw.w, "\r\x1b[2K")
+	n, err := pw.w.Write(b)
+	_, _ = io.WriteString(pw.w, pw.line)
+	return n, err
+}
+
+// draw replaces the current progress line with the provided line.
+func (pw *progressWriter) draw(line string) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.line = line
+	_, _ = io.WriteString(pw.w, "\r\x1b[2K"+line)
+}
+
+// finish replaces the current progress line with the provided line, and moves
+// to the next line, so future writes don't clear it.
+func (pw *progressWriter) finish(line string) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.line = ""
+	_, _ = io.WriteString(pw.w, "\r\x1b[2K"+line+"\n")
+}
+
+// Progress creates and starts a new progress reporter. If total is <= 0, a
+// spinner is used, otherwise a progress bar. On a TTY, the progress is drawn on
+// stderr, and log entries from the logging plugin are written above it. Drawing
+// relies on ANSI escape sequences, so when stderr is not a TTY, colors are
+// disabled (e.g. --color=never, NO_COLOR or TERM=dumb), or when using --log.json,
+// progress is instead reported periodically through slog records, as plain lines.
+// [Progress.Done] must be called when finished.
+func (cli *CLI[T]) Progress(title string, total int64) *Progress {
+	t := cli.Term()
+	tty := t.StderrTTY() && t.StderrColor() &&
+		(cli.logging == nil || !cli.logging.JSON || cli.logging.Path != "")
+
+	logger := cli.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	p := newProgress(title, total, cli.errWriter(cli.Context), logger, tty, t.Width())
+	p.start(progressRenderInterval, progressLogInterval)
+	return p
+}
+
+// Progress reports the progress of a long-running task. Obtain one via
+// [CLI.Progress]. All methods are safe for concurrent use.
+type Progress struct {
+	title   string
+	total   int64
+	current atomic.Int64
+	message atomic.Value
+
+	out    *progressWriter
+	logger *slog.Logger
+	tty    bool
+	width  int
+
+	started time.Time
+	frame   int
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+func newProgress(title string, total int64, out *progressWriter, logger *slog.Logger, tty bool, width int) *Progress {
+	p := &Progress{
+		title:   title,
+		total:   total,
+		out:     out,
+		logger:  logger,
+		tty:     tty,
+		width:   width,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+	}
+	p.message.Store("")
+	return p
+}
+
+func (p *Progress) start(renderInterval, logInterval time.Duration) {
+	interval := logInterval
+	if p.tty {
+		interval = renderInterval
+	}
+
+	p.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.render()
+			}
+		}
+	})
+}
+
+// Add adds n to the current progress.
+func (p *Progress) Add(n int64) {
+	p.current.Add(n)
+}
+
+// Set sets the current progress.
+func (p *Progress) Set(n int64) {
+	p.current.Store(n)
+}
+
+// SetMessage sets an additional message which is shown alongside the progress.
+func (p *Progress) SetMessage(msg string) {
+	p.message.Store(msg)
+}
+
+// Write implements [io.Writer], adding the number of bytes written to the
+// progress. Useful with [io.Copy] and [io.TeeReader].
+func (p *Progress) Write(b []byte) (int, error) {
+	p.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Done stops the progress reporter, drawing (or logging) the final state. The
+// progress is only marked as completed if the total was reached.
+func (p *Progress) Done() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+
+		if p.tty {
+			p.out.finish(p.line(true))
+			return
+		}
+
+		msg := "progress completed"
+		if !p.complete() {
+			msg = "progress stopped"
+		}
+
+		p.logger.Info( //nolint:sloglint
+			msg,
+			"title", p.title,
+			"current", p.current.Load(),
+			"total", p.total,
+			"elapsed", time.Since(p.started).Round(time.Millisecond),
+		)
+	})
+}
+
+func (p *Progress) render() {
+	if p.tty {
+		p.frame++
+		p.out.draw(p.line(false))
+		return
+	}
+
+	attrs := []any{"title", p.title, "current", p.current.Load()}
+	if p.total > 0 {
+		attrs = append(attrs, "total", p.total, "percent", fmt.Sprintf("%.1f", p.percent()*100))
+	}
+	if msg := p.message.Load().(string); msg != "" { //nolint:errcheck
+		attrs = append(attrs, "message", msg)
+	}
+	p.logger.Info("progress", attrs...) //nolint:sloglint
+}
+
+// complete returns true if the total was reached, or if there is no total (i.e.
+// a spinner).
+func (p *Progress) complete() bool {
+	return p.total <= 0 || p.current.Load() >= p.total
+}
+
+func (p *Progress) percent() float64 {
+	if p.total <= 0 {
+		return 0
+	}
+	return min(1, max(0, float64(p.current.Load())/float64(p.total)))
+}
+
+// line returns the progress line to draw.
+func (p *Progress) line(done bool) string {
+	var b strings.Builder
+
+	switch {
+	case done && p.complete():
+		b.WriteString("✓ ")
+	case done:
+		b.WriteString("✗ ")
+	case p.total <= 0:
+		b.WriteString(spinnerFrames[p.frame%len(spinnerFrames)] + " ")
+	}
+
+	b.WriteString(p.title)
+
+	current := p.current.Load()
+
+	if p.total > 0 {
+		suffix := fmt.Sprintf(" %3.0f%% (%d/%d)", p.percent()*100, current, p.total)
+
+		// Bar uses the remaining width, within reason.
+		barWidth := min(40, p.width-utf8.RuneCountInString(b.String())-len(suffix)-3)
+		if barWidth >= 10 {
+			filled := int(p.percent() * float64(barWidth))
+			b.WriteString(" [")
+			b.WriteString(strings.Repeat("=", filled))
+			if filled < barWidth {
+				b.WriteString(">")
+				b.WriteString(strings.Repeat(" ", barWidth-filled-1))
+			}
+			b.WriteString("]")
+		}
+		b.WriteString(suffix)
+	} else if current > 0 {
+		fmt.Fprintf(&b, " (%d)", current)
+	}
+
+	if msg := p.message.Load().(string); msg != "" { //nolint:errcheck
+		b.WriteString(" :: " + msg)
+	}
+
+	if done {
+		fmt.Fprintf(&b, " [%s]", time.Since(p.started).Round(time.Millisecond))
+	}
+
+	return truncate(b.String(), max(p.width-1, minTableColumnWidth))
+}
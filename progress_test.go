@@ -0,0 +1,124 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestProgressWriterCoordinatesLogs(t *testing.T) {
+	var buf bytes.Buffer
+	pw := &progressWriter{w: &buf}
+
+	logger := slog.New(slog.NewTextHandler(pw, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	p := newProgress("downloading", 100, pw, logger, true, 80)
+	p.Set(50)
+	p.render()
+
+	buf.Reset()
+	logger.Info("hello")
+
+	// Log line should clear the bar, write the entry, then redraw the bar.
+	out := buf.String()
+	if !strings.HasPrefix(out, "\r\x1b[2Klevel=INFO msg=hello\n") {
+		t.Fatalf("expected log entry to clear progress line first, got %q", out)
+	}
+	if !strings.HasSuffix(out, p.line(false)) {
+		t.Fatalf("expected progress line to be redrawn after log entry, got %q", out)
+	}
+	if !strings.Contains(out, "50% (50/100)") {
+		t.Fatalf("expected progress to be redrawn with percentage, got %q", out)
+	}
+
+	p.Done()
+
+	buf.Reset()
+	logger.Info("after")
+	if buf.String() != "level=INFO msg=after\n" {
+		t.Fatalf("expected no progress redraw after done, got %q", buf.String())
+	}
+}
+
+func TestProgressLogFallback(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	p := newProgress("processing", 10, &progressWriter{w: &bytes.Buffer{}}, logger, false, 80)
+	p.start(time.Millisecond, 5*time.Millisecond)
+	p.Add(5)
+
+	time.Sleep(50 * time.Millisecond)
+	p.Add(5)
+	p.Done()
+
+	out := logs.String()
+	for _, e := range []string{`"msg":"progress"`, `"title":"processing"`, `"total":10`, `"msg":"progress completed"`} {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q in progress logs, got:\n%s", e, out)
+		}
+	}
+}
+
+func TestProgressDoneIncomplete(t *testing.T) {
+	var buf bytes.Buffer
+
+	p := newProgress("downloading", 100, &progressWriter{w: &buf}, slog.Default(), true, 80)
+	p.Set(50)
+	p.Done()
+
+	if out := buf.String(); strings.Contains(out, "✓") || !strings.Contains(out, "✗ downloading") {
+		t.Fatalf("expected incomplete progress to not be marked as completed, got %q", out)
+	}
+
+	var logs bytes.Buffer
+	p = newProgress("downloading", 100, &progressWriter{w: &buf}, slog.New(slog.NewTextHandler(&logs, nil)), false, 80)
+	p.Set(50)
+	p.Done()
+
+	if !strings.Contains(logs.String(), `msg="progress stopped"`) {
+		t.Fatalf("expected incomplete progress to be logged as stopped, got %q", logs.String())
+	}
+}
+
+func TestLoggingUsesKongStderr(t *testing.T) {
+	type Flags struct{}
+
+	oldArgs, oldLogger := os.Args, slog.Default()
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		slog.SetDefault(oldLogger)
+	})
+	os.Args = []string{"testapp", "--color=never"}
+
+	var buf bytes.Buffer
+	cli := New(
+		WithKongOptions[Flags](kong.Writers(&bytes.Buffer{}, &buf)),
+		WithLoggingPlugin[Flags](false, nil),
+	)
+
+	cli.GetLogger().Info("hello")
+	if !strings.Contains(buf.String(), "msg=hello") {
+		t.Fatalf("expected log entry in kong stderr writer, got %q", buf.String())
+	}
+
+	if w := cli.errWriter(cli.Context); w == stderr || w.w != &buf {
+		t.Fatal("expected progress to use the kong stderr writer")
+	}
+}
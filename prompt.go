@@ -0,0 +1,211 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+	"golang.org/x/term"
+)
+
+// maxPromptAttempts is the maximum number of times a user will be prompted for
+// a single flag, before giving up.
+const maxPromptAttempts = 3
+
+// promptStdio returns the input and output used for prompting, and whether both
+// stdin and stdout are a TTY. Replaced in tests.
+var promptStdio = func() (in io.Reader, out io.Writer, tty bool) {
+	return os.Stdin, os.Stderr, isTTY(os.Stdin) && isTTY(os.Stdout)
+}
+
+// WithPromptPlugin adds support for interactively prompting for missing required
+// flags, rather than failing with a usage error. Prompts are only shown when both
+// stdin and stdout are a TTY, and can be disabled with the --no-input flag (or
+// the NO_INPUT environment variable).
+//
+// The prompt style depends on the flag:
+//
+//   - Flags with a "secret" tag (e.g. `secret:""`) use hidden input.
+//   - Flags with an "enum" tag use a selection list.
+//   - Boolean flags use a yes/no confirmation.
+//
+// Flags which are part of an "xor" or "and" group are not prompted for.
+func WithPromptPlugin[T any]() Option[T] {
+	var initialized atomic.Bool
+
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+
+		cli.Plugins = append(cli.Plugins, &PromptPlugin{})
+		cli.kongOptions = append(cli.kongOptions, kong.WithBeforeApply(func(kctx *kong.Context) error {
+			if initialized.Swap(true) {
+				return nil
+			}
+
+			in, out, tty := promptStdio()
+			if !tty {
+				return nil
+			}
+
+			for _, flag := range kctx.Flags() {
+				if flag.Name == "no-input" {
+					if v, _ := kctx.FlagValue(flag).(bool); v {
+						return nil
+					}
+				}
+			}
+
+			return newPrompter(in, out).promptMissing(kctx.Flags())
+		}))
+	}
+}
+
+// PromptPlugin are the flags that control interactive prompting.
+type PromptPlugin struct {
+	// NoInput disables interactive prompts.
+	NoInput bool `name:"no-input" env:"NO_INPUT" help:"disables interactive prompts for missing required flags"`
+}
+
+type prompter struct {
+	in  *bufio.Reader
+	fd  int // File descriptor of the input, or -1 if not a terminal.
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	p := &prompter{
+		in:  bufio.NewReader(in),
+		fd:  -1,
+		out: out,
+	}
+
+	if f, ok := in.(*os.File); ok && isTTY(f) {
+		p.fd = int(f.Fd()) //nolint:gosec
+	}
+
+	return p
+}
+
+// promptMissing prompts for all required flags that have not been set through
+// arguments, environment variables, defaults or resolvers.
+func (p *prompter) promptMissing(flags []*kong.Flag) error {
+	for _, flag := range flags {
+		if !flag.Required || flag.Set || len(flag.Xor) > 0 || len(flag.And) > 0 {
+			continue
+		}
+
+		if err := p.promptFlag(flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *prompter) promptFlag(flag *kong.Flag) error {
+	for range maxPromptAttempts {
+		input, err := p.read(flag)
+		if err != nil {
+			return fmt.Errorf("failed to prompt for --%s: %w", flag.Name, err)
+		}
+
+		if flag.Enum != "" && !flag.EnumMap()[input] {
+			fmt.Fprintf(p.out, "invalid value: must be one of %s\n", strings.Join(flag.EnumSlice(), ","))
+			continue
+		}
+
+		err = flag.Parse(kong.ScanFromTokens(kong.Token{
+			Type:  kong.FlagValueToken,
+			Value: input,
+		}), flag.Target)
+		if err == nil {
+			return nil
+		}
+
+		fmt.Fprintf(p.out, "invalid value: %v\n", err)
+	}
+
+	return fmt.Errorf("--%s: too many invalid attempts", flag.Name)
+}
+
+// read prompts for and returns the raw input for the provided flag.
+func (p *prompter) read(flag *kong.Flag) (string, error) {
+	label := "--" + flag.Name
+	if flag.Help != "" {
+		label += " (" + flag.Help + ")"
+	}
+	if len(flag.Envs) > 0 {
+		label += " [env: " + strings.Join(flag.Envs, ", ") + "]"
+	}
+
+	switch {
+	case flag.IsBool():
+		fmt.Fprintf(p.out, "? %s [y/N]: ", label)
+		input, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		switch strings.ToLower(input) {
+		case "y", "yes":
+			return "true", nil
+		case "", "n", "no":
+			return "false", nil
+		default:
+			return input, nil
+		}
+	case flag.Enum != "":
+		options := flag.EnumSlice()
+
+		fmt.Fprintf(p.out, "? %s\n", label)
+		for i, opt := range options {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, opt)
+		}
+		fmt.Fprintf(p.out, "select [1-%d]: ", len(options))
+
+		input, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if i, err := strconv.Atoi(input); err == nil && i >= 1 && i <= len(options) {
+			return options[i-1], nil
+		}
+		return input, nil
+	case flag.Tag.Has("secret"):
+		fmt.Fprintf(p.out, "? %s: ", label)
+
+		if p.fd < 0 {
+			return p.readLine()
+		}
+
+		b, err := term.ReadPassword(p.fd)
+		fmt.Fprintln(p.out)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	default:
+		fmt.Fprintf(p.out, "? %s: ", label)
+		return p.readLine()
+	}
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestPromptMissing(t *testing.T) {
+	var flags struct {
+		Name     string `name:"name" required:"" env:"CLIX_PROMPT_NAME" help:"name"`
+		Password string `name:"password" required:"" secret:"" help:"password"`
+		Level    string `name:"level" required:"" enum:"low,high" help:"level"`
+		Force    bool   `name:"force" required:"" help:"force"`
+		Optional string `name:"optional" help:"not prompted"`
+	}
+
+	parser, err := kong.New(&flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kctx, err := kong.Trace(parser, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = kctx.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	p := newPrompter(strings.NewReader("foo\nhunter2\ninvalid\n2\ny\n"), &out)
+
+	if err = p.promptMissing(kctx.Flags()); err != nil {
+		t.Fatal(err)
+	}
+
+	if flags.Name != "foo" || flags.Password != "hunter2" || flags.Level != "high" || !flags.Force {
+		t.Fatalf("unexpected flag values: %+v", flags)
+	}
+
+	for _, e := range []string{"CLIX_PROMPT_NAME", "1) low", "2) high", "invalid value", "[y/N]"} {
+		if !strings.Contains(out.String(), e) {
+			t.Fatalf("expected %q to be in prompt output, got:\n%s", e, out.String())
+		}
+	}
+
+	if err = kctx.Validate(); err != nil {
+		t.Fatalf("expected prompted flags to pass validation, got: %v", err)
+	}
+}
+
+func TestWithPromptPlugin(t *testing.T) {
+	type Flags struct {
+		Name string `name:"name" required:"" help:"name"`
+	}
+
+	tests := []struct {
+		name    string
+		tty     bool
+		args    []string
+		env     string
+		prompts bool
+	}{
+		{name: "tty", tty: true, prompts: true},
+		{name: "not-tty", tty: false},
+		{name: "no-input-flag", tty: true, args: []string{"--no-input"}},
+		{name: "no-input-env", tty: true, env: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldArgs, oldStdio := os.Args, promptStdio
+			t.Cleanup(func() {
+				os.Args = oldArgs
+				promptStdio = oldStdio
+			})
+			os.Args = append([]string{"testapp"}, tt.args...)
+
+			if tt.env != "" {
+				t.Setenv("NO_INPUT", tt.env)
+			}
+
+			var out strings.Builder
+			promptStdio = func() (io.Reader, io.Writer, bool) {
+				return strings.NewReader("foo\n"), &out, tt.tty
+			}
+
+			var stderr bytes.Buffer
+			code := -1
+
+			cli := New(
+				WithKongOptions[Flags](
+					kong.Writers(&bytes.Buffer{}, &stderr),
+					kong.Exit(func(c int) {
+						if code == -1 {
+							code = c
+						}
+					}),
+				),
+				WithPromptPlugin[Flags](),
+			)
+
+			if tt.prompts {
+				if code != -1 || cli.Flags.Name != "foo" {
+					t.Fatalf("expected --name to be prompted for, got exit code %d and name %q", code, cli.Flags.Name)
+				}
+				if !strings.Contains(out.String(), "--name") {
+					t.Fatalf("expected prompt output, got %q", out.String())
+				}
+				return
+			}
+
+			if out.Len() > 0 {
+				t.Fatalf("expected no prompt, got %q", out.String())
+			}
+			// Kong exits with 80 on usage errors.
+			if code != 80 || !strings.Contains(stderr.String(), "missing flags: --name") {
+				t.Fatalf("expected missing flag error, got exit code %d:\n%s", code, stderr.String())
+			}
+		})
+	}
+}
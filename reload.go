@@ -0,0 +1,324 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+// WithReloadPlugin enables hot reloading of flags, which is useful for long-running
+// daemons. Files passed to [WithEnvFiles], as well as any additional paths
+// provided (e.g. config files used with [kong.Configuration]), are polled for
+// changes at the provided interval (or never, if the interval is <= 0). A reload
+// is also triggered when the process receives SIGHUP. Use [CLI.StopReload] to
+// stop watching.
+//
+// On reload, env files are re-read, and the original arguments are re-parsed into
+// a fresh copy of the flags (and plugins), which are validated the same way as the
+// initial parse. Nothing is changed until the new flags are valid, at which point
+// the env files are applied to the environment, the new flags are published
+// through [CLI.CurrentFlags], and all subscribers registered through
+// [CLI.OnReload] are notified. Invalid reloads are rejected and logged, leaving
+// the running flags and environment untouched.
+func WithReloadPlugin[T any](interval time.Duration, paths ...string) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+
+		cli.reloader = &reloader[T]{
+			interval: interval,
+			paths:    paths,
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+
+		cli.kongOptions = append(cli.kongOptions, kong.WithAfterApply(func() error {
+			if initialized.Swap(true) {
+				return nil
+			}
+			cli.reloader.start(cli)
+			return nil
+		}))
+	}
+}
+
+// OnReload registers a function which will be invoked with the previous and new
+// flags, after a successful reload. Only used when [WithReloadPlugin] is enabled.
+func (cli *CLI[T]) OnReload(fn func(prev, next *T)) {
+	if cli.reloader == nil {
+		return
+	}
+
+	cli.reloader.subMu.Lock()
+	cli.reloader.subscribers = append(cli.reloader.subscribers, fn)
+	cli.reloader.subMu.Unlock()
+}
+
+// CurrentFlags returns the flags from the last successful reload (see
+// [WithReloadPlugin]), or [CLI.Flags] if there hasn't been one. [CLI.Flags]
+// itself is never replaced, so it is safe to read concurrently with reloads.
+func (cli *CLI[T]) CurrentFlags() *T {
+	if cli.reloader != nil {
+		if flags := cli.reloader.current.Load(); flags != nil {
+			return flags
+		}
+	}
+	return cli.Flags
+}
+
+// StopReload stops watching for SIGHUP and file changes, waiting for any
+// in-progress reload to finish. [CLI.Reload] can still be called manually. Only
+// used when [WithReloadPlugin] is enabled.
+func (cli *CLI[T]) StopReload() {
+	if cli.reloader == nil {
+		return
+	}
+
+	cli.reloader.stopOnce.Do(func() { close(cli.reloader.stop) })
+	if cli.reloader.started.Load() {
+		<-cli.reloader.done
+	}
+}
+
+// Reload re-reads any env files, and re-parses the flags. If the new flags are
+// valid, the env files are applied to the environment, the new flags are
+// published through [CLI.CurrentFlags], and all subscribers registered through
+// [CLI.OnReload] are notified. Requires [WithReloadPlugin] to be enabled.
+func (cli *CLI[T]) Reload() error {
+	if cli.reloader == nil {
+		return errors.New("reload plugin not enabled")
+	}
+
+	cli.reloader.mu.Lock()
+	defer cli.reloader.mu.Unlock()
+
+	var vars map[string]string
+	lookupEnv := os.LookupEnv
+
+	if cli.envFiles != nil {
+		var err error
+		vars, err = cli.envFiles.parse()
+		if err != nil {
+			return fmt.Errorf("failed to reload env files: %w", err)
+		}
+		lookupEnv = cli.envFiles.overlay(vars)
+	}
+
+	// Resolvers registered by plugins (e.g. [WithFileEnvVars]) read the
+	// environment through the original CLI, so point it at the overlay for the
+	// duration of the parse.
+	cli.lookupEnv = lookupEnv
+	defer func() { cli.lookupEnv = nil }()
+
+	next := &CLI[T]{
+		Plugins: clonePlugins(cli.Plugins),
+		Flags:   new(T),
+	}
+
+	// Hooks registered through options are only invoked for the initial parse, so
+	// only bindings (used by hooks on the flags themselves) need to point at the
+	// new CLI.
+	options := append(
+		slices.Clone(cli.kongOptions),
+		kong.Bind(next),
+		kong.WithBeforeResolve(func(kctx *kong.Context) error {
+			return resetEnvValues(kctx.Model.Node, lookupEnv)
+		}),
+	)
+
+	parser, err := kong.New(next, options...)
+	if err != nil {
+		return err
+	}
+
+	_, err = parser.Parse(os.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	if cli.envFiles != nil {
+		if err = cli.envFiles.apply(vars); err != nil {
+			return fmt.Errorf("failed to apply env files: %w", err)
+		}
+	}
+
+	prev := cli.CurrentFlags()
+	cli.reloader.current.Store(next.Flags)
+
+	cli.reloader.subMu.Lock()
+	subscribers := slices.Clone(cli.reloader.subscribers)
+	cli.reloader.subMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(prev, next.Flags)
+	}
+
+	return nil
+}
+
+// resetEnvValues re-resets all values within the node (and its children) which
+// have environment variables that differ between the process environment and
+// lookupEnv, the same way [kong.Value.Reset] does with the process environment.
+// Values from the command line are applied afterwards, so they aren't affected.
+func resetEnvValues(node *kong.Node, lookupEnv lookupEnvFunc) error {
+	return kong.Visit(node, func(n kong.Visitable, next kong.Next) error {
+		value, ok := n.(*kong.Value)
+		if !ok || value.Tag == nil {
+			return next(nil)
+		}
+
+		changed := slices.ContainsFunc(value.Tag.Envs, func(env string) bool {
+			v1, ok1 := os.LookupEnv(env)
+			v2, ok2 := lookupEnv(env)
+			return v1 != v2 || ok1 != ok2
+		})
+		if !changed {
+			return next(nil)
+		}
+
+		value.Target.Set(reflect.Zero(value.Target.Type()))
+		for _, env := range value.Tag.Envs {
+			if v, ok := lookupEnv(env); ok {
+				err := value.Parse(kong.ScanFromTokens(kong.Token{Type: kong.FlagValueToken, Value: v}), value.Target)
+				if err != nil {
+					return next(fmt.Errorf("%w (from envar %s=%q)", err, env, v))
+				}
+				return next(nil)
+			}
+		}
+
+		if value.HasDefault {
+			return next(value.Parse(kong.ScanFromTokens(kong.Token{Type: kong.FlagValueToken, Value: value.Default}), value.Target))
+		}
+		return next(nil)
+	})
+}
+
+// clonePlugins returns shallow copies of the plugin structs (see [kong.Plugins]),
+// so they can be parsed into without modifying the originals. Pointers to
+// embedded structs don't need to be copied, as kong always allocates new ones.
+func clonePlugins(plugins kong.Plugins) kong.Plugins {
+	clones := make(kong.Plugins, 0, len(plugins))
+	for _, plugin := range plugins {
+		v := reflect.ValueOf(plugin)
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			clones = append(clones, plugin)
+			continue
+		}
+
+		clone := reflect.New(v.Elem().Type())
+		clone.Elem().Set(v.Elem())
+		clones = append(clones, clone.Interface())
+	}
+	return clones
+}
+
+type reloader[T any] struct {
+	interval time.Duration
+	paths    []string
+
+	mu      sync.Mutex // Serializes reloads.
+	current atomic.Pointer[T]
+
+	subMu       sync.Mutex
+	subscribers []func(prev, next *T)
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// fileState is used to detect changes to watched files.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFiles(paths []string) map[string]fileState {
+	states := make(map[string]fileState, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[path] = fileState{}
+			continue
+		}
+		states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return states
+}
+
+// start starts watching for SIGHUP and file changes in the background. The
+// signal handler is registered (and files are stat'd) before returning, so
+// nothing is missed once parsing has finished.
+func (r *reloader[T]) start(cli *CLI[T]) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	paths := r.paths
+	if cli.envFiles != nil {
+		paths = append(slices.Clone(cli.envFiles.paths), paths...)
+	}
+
+	r.started.Store(true)
+	go r.watch(cli, sig, paths, statFiles(paths))
+}
+
+func (r *reloader[T]) watch(cli *CLI[T], sig chan os.Signal, paths []string, states map[string]fileState) {
+	defer close(r.done)
+	defer signal.Stop(sig)
+
+	var tick <-chan time.Time
+	if r.interval > 0 && len(paths) > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-sig:
+			r.reload(cli, "signal")
+		case <-tick:
+			current := statFiles(paths)
+			if maps.Equal(states, current) {
+				continue
+			}
+			states = current
+			r.reload(cli, "file change")
+		}
+	}
+}
+
+func (r *reloader[T]) reload(cli *CLI[T], trigger string) {
+	logger := cli.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	if err := cli.Reload(); err != nil {
+		logger.Error("reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+
+	logger.Info("reloaded configuration", "trigger", trigger)
+}
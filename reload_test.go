@@ -0,0 +1,259 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithReloadPlugin(t *testing.T) {
+	type Flags struct {
+		Name string `name:"name" env:"CLIX_RELOAD_NAME" enum:"foo,bar" default:"foo" help:"name"`
+	}
+
+	fn := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv("CLIX_RELOAD_NAME") })
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	cli := New(
+		WithEnvFiles[Flags](fn),
+		WithReloadPlugin[Flags](0),
+	)
+	t.Cleanup(cli.StopReload)
+
+	if cli.Flags.Name != "foo" {
+		t.Fatalf("expected initial name to be foo, got %q", cli.Flags.Name)
+	}
+
+	var calls int
+	cli.OnReload(func(prev, next *Flags) {
+		calls++
+		if prev.Name != "foo" || next.Name != "bar" {
+			t.Fatalf("unexpected reload values: prev=%q next=%q", prev.Name, next.Name)
+		}
+	})
+
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=bar\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected 1 reload notification, got %d", calls)
+	}
+
+	if cli.CurrentFlags().Name != "bar" {
+		t.Fatalf("expected name to be bar after reload, got %q", cli.CurrentFlags().Name)
+	}
+
+	if cli.Flags.Name != "foo" {
+		t.Fatalf("expected initial flags to be untouched, got %q", cli.Flags.Name)
+	}
+
+	if v := os.Getenv("CLIX_RELOAD_NAME"); v != "bar" {
+		t.Fatalf("expected env file to be applied after reload, got %q", v)
+	}
+
+	// Invalid reloads should be rejected, and not touch the running flags.
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=invalid\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.Reload(); err == nil {
+		t.Fatal("expected error when reloading invalid value")
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected no reload notification for invalid reload, got %d", calls)
+	}
+
+	if cli.CurrentFlags().Name != "bar" {
+		t.Fatalf("expected name to remain bar after invalid reload, got %q", cli.CurrentFlags().Name)
+	}
+
+	if v := os.Getenv("CLIX_RELOAD_NAME"); v != "bar" {
+		t.Fatalf("expected environment to be untouched after invalid reload, got %q", v)
+	}
+}
+
+func TestReloadPlugins(t *testing.T) {
+	type Flags struct {
+		Name string `name:"name" help:"name"`
+	}
+
+	type Plugin struct {
+		Level string `name:"level" env:"CLIX_RELOAD_LEVEL" enum:"info,warn" default:"info" help:"level"`
+	}
+
+	fn := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_LEVEL=warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv("CLIX_RELOAD_LEVEL") })
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	plugin := &Plugin{}
+
+	cli := New(
+		func(cli *CLI[Flags]) { cli.Plugins = append(cli.Plugins, plugin) },
+		WithEnvFiles[Flags](fn),
+		WithReloadPlugin[Flags](0),
+	)
+	t.Cleanup(cli.StopReload)
+
+	for _, content := range []string{"CLIX_RELOAD_LEVEL=info\n", "CLIX_RELOAD_LEVEL=invalid\n"} {
+		if err := os.WriteFile(fn, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_ = cli.Reload()
+
+		if plugin.Level != "warn" {
+			t.Fatalf("expected plugin flags to be untouched by reload, got %q", plugin.Level)
+		}
+	}
+}
+
+func TestReloadFileEnvVars(t *testing.T) {
+	type Flags struct {
+		Secret string `name:"secret" env:"CLIX_TEST_SECRET" help:"secret"`
+	}
+
+	fn := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(fn, []byte("hunter2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CLIX_TEST_SECRET_FILE", fn)
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	cli := New(
+		WithFileEnvVars[Flags](),
+		WithReloadPlugin[Flags](0),
+	)
+	t.Cleanup(cli.StopReload)
+
+	if err := os.WriteFile(fn, []byte("hunter3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cli.CurrentFlags().Secret != "hunter3" {
+		t.Fatalf("expected secret to be re-read from file on reload, got %q", cli.CurrentFlags().Secret)
+	}
+}
+
+// newWatchedCLI returns a CLI watching an env file, and a channel which receives
+// the new name on every reload.
+func newWatchedCLI(t *testing.T, interval time.Duration) (fn string, cli *CLI[reloadFlags], names chan string) {
+	t.Helper()
+
+	fn = filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.Unsetenv("CLIX_RELOAD_NAME") })
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp"}
+
+	cli = New(
+		WithEnvFiles[reloadFlags](fn),
+		WithReloadPlugin[reloadFlags](interval),
+	)
+	t.Cleanup(cli.StopReload)
+
+	names = make(chan string, 10)
+	cli.OnReload(func(_, next *reloadFlags) { names <- next.Name })
+	return fn, cli, names
+}
+
+type reloadFlags struct {
+	Name string `name:"name" env:"CLIX_RELOAD_NAME" help:"name"`
+}
+
+func waitReload(t *testing.T, names chan string, want string) {
+	t.Helper()
+
+	select {
+	case name := <-names:
+		if name != want {
+			t.Fatalf("expected name to be %q after reload, got %q", want, name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
+
+func TestReloadSignal(t *testing.T) {
+	fn, cli, names := newWatchedCLI(t, 0)
+
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=bar\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("unable to send SIGHUP: %v", err)
+	}
+
+	waitReload(t, names, "bar")
+
+	if cli.CurrentFlags().Name != "bar" {
+		t.Fatalf("expected name to be bar after SIGHUP, got %q", cli.CurrentFlags().Name)
+	}
+}
+
+func TestReloadWatch(t *testing.T) {
+	fn, cli, names := newWatchedCLI(t, 10*time.Millisecond)
+
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=bar-baz\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	waitReload(t, names, "bar-baz")
+
+	cli.StopReload()
+
+	if err := os.WriteFile(fn, []byte("CLIX_RELOAD_NAME=qux\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case name := <-names:
+		t.Fatalf("expected no reload after stopping, got %q", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
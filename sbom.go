@@ -0,0 +1,373 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+// Supported SBOM formats. See [Version.WriteSBOM].
+const (
+	SBOMFormatSPDX      = "spdx"      // SPDX 2.3 JSON.
+	SBOMFormatCycloneDX = "cyclonedx" // CycloneDX 1.5 JSON.
+)
+
+// WithSBOMPlugin adds a hidden "sbom" command to the CLI, which writes a software
+// bill of materials for the binary to stdout (see [Version.WriteSBOM]). Like the
+// markdown plugin, it's invoked before kong applies additional restrictions, so
+// it ignores any other required flags.
+func WithSBOMPlugin[T any]() Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Swap(true) {
+			return
+		}
+
+		cli.kongOptions = append(
+			cli.kongOptions, kong.DynamicCommand(
+				"sbom",
+				"write a software bill of materials (SBOM) for the binary to stdout",
+				"",
+				&SBOMCommand{},
+				"hidden",
+			),
+		)
+	}
+}
+
+// SBOMCommand is the command added by [WithSBOMPlugin].
+type SBOMCommand struct {
+	Format string `short:"f" name:"format" default:"spdx" enum:"spdx,cyclonedx" help:"SBOM format"`
+}
+
+func (c *SBOMCommand) BeforeReset(kctx *kong.Context, path *kong.Path, version *Version) error {
+	if err := version.WriteSBOM(kctx.Stdout, earlyFlagValue(kctx, path.Command, "format")); err != nil {
+		return err
+	}
+	kctx.Exit(0)
+	return nil
+}
+
+// WriteSBOM writes a software bill of materials (SBOM) for the binary to w, in
+// the provided format (see the SBOMFormat* constants). The SBOM is generated
+// from the embedded build information, and includes the main module, the Go
+// toolchain, build settings, dependencies and their replacements, so compliance
+// scans can be run against shipped binaries without access to the source.
+func (v *Version) WriteSBOM(w io.Writer, format string) error {
+	var doc any
+
+	switch format {
+	case SBOMFormatSPDX:
+		doc = v.spdx(time.Now().UTC())
+	case SBOMFormatCycloneDX:
+		doc = v.cycloneDX(time.Now().UTC())
+	default:
+		return fmt.Errorf(
+			"unknown SBOM format %q, must be one of: %s, %s",
+			format, SBOMFormatSPDX, SBOMFormatCycloneDX,
+		)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(doc)
+}
+
+// sbomPackage is a package included in the SBOM.
+type sbomPackage struct {
+	id       string
+	path     string
+	version  string
+	sum      string
+	replaces *Module // Original module, if this package is a replacement.
+	noPURL   bool    // Path isn't a module path, so there's no valid purl.
+}
+
+func (p *sbomPackage) purl() string {
+	if p.noPURL || p.version == "" {
+		return "" // Local replacements have no version, and no valid purl.
+	}
+	return "pkg:golang/" + p.path + "@" + p.version
+}
+
+// sbomPackages returns the main module, toolchain and dependency packages. The
+// first package is always the main module.
+func (v *Version) sbomPackages() []*sbomPackage {
+	pkgs := []*sbomPackage{
+		{id: "main", path: v.mainPath, version: v.AppInfo.Version},
+		{id: "toolchain", path: "stdlib", version: v.GoVersion},
+	}
+
+	// The application name may not be the module path (e.g. when set through
+	// [WithAppInfo]), so it's only used when there's no build info.
+	if pkgs[0].path == "" {
+		pkgs[0].path, pkgs[0].noPURL = v.AppInfo.Name, true
+	}
+
+	if v.AppInfo.Version == "unknown" || v.AppInfo.Version == "(devel)" {
+		pkgs[0].version = ""
+	}
+
+	for i, dep := range v.Dependencies {
+		pkg := &sbomPackage{
+			id:      fmt.Sprintf("dep-%d", i),
+			path:    dep.Path,
+			version: dep.Version,
+			sum:     dep.Sum,
+		}
+
+		if dep.Replace != nil {
+			orig := dep
+			orig.Replace = nil
+			pkg.replaces = &orig
+
+			r := dep.Resolved()
+			pkg.path, pkg.version, pkg.sum = r.Path, r.Version, r.Sum
+		}
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs
+}
+
+// sbomID returns a stable identifier for the SBOM document.
+func (v *Version) sbomID() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s@%s:%s:%s/%s", v.AppInfo.Name, v.AppInfo.Version, v.AppInfo.Commit, v.OS, v.Arch)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string           `json:"name"`
+	SPDXID           string           `json:"SPDXID"`
+	VersionInfo      string           `json:"versionInfo,omitempty"`
+	DownloadLocation string           `json:"downloadLocation"`
+	FilesAnalyzed    bool             `json:"filesAnalyzed"`
+	LicenseConcluded string           `json:"licenseConcluded"`
+	LicenseDeclared  string           `json:"licenseDeclared"`
+	CopyrightText    string           `json:"copyrightText"`
+	Comment          string           `json:"comment,omitempty"`
+	ExternalRefs     []spdxExternal   `json:"externalRefs,omitempty"`
+	Annotations      []spdxAnnotation `json:"annotations,omitempty"`
+}
+
+type spdxExternal struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxAnnotation struct {
+	AnnotationDate string `json:"annotationDate"`
+	AnnotationType string `json:"annotationType"`
+	Annotator      string `json:"annotator"`
+	Comment        string `json:"comment"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func (v *Version) spdx(now time.Time) *spdxDocument {
+	created := now.Format(time.RFC3339)
+
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              v.AppInfo.Name + "@" + v.AppInfo.Version,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + strings.ReplaceAll(v.AppInfo.Name, "/", "-") + "-" + v.sbomID(),
+		CreationInfo: spdxCreationInfo{
+			Created:  created,
+			Creators: []string{"Tool: clix", "Tool: " + v.GoVersion},
+		},
+	}
+
+	for i, pkg := range v.sbomPackages() {
+		p := spdxPackage{
+			Name:             pkg.path,
+			SPDXID:           "SPDXRef-Package-" + pkg.id,
+			VersionInfo:      pkg.version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}
+
+		if purl := pkg.purl(); purl != "" {
+			p.ExternalRefs = append(p.ExternalRefs, spdxExternal{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  purl,
+			})
+		}
+
+		var comments []string
+		if pkg.sum != "" {
+			comments = append(comments, "go.sum: "+pkg.sum)
+		}
+		if pkg.replaces != nil {
+			comments = append(comments, "replaces: "+pkg.replaces.Path+" "+pkg.replaces.Version)
+		}
+		p.Comment = strings.Join(comments, "\n")
+
+		if i == 0 {
+			for _, s := range v.Settings {
+				p.Annotations = append(p.Annotations, spdxAnnotation{
+					AnnotationDate: created,
+					AnnotationType: "OTHER",
+					Annotator:      "Tool: clix",
+					Comment:        "go.build." + s.Key + "=" + s.Value,
+				})
+			}
+
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      doc.SPDXID,
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: p.SPDXID,
+			})
+		} else {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      doc.Packages[0].SPDXID,
+				RelationshipType:   "DEPENDS_ON",
+				RelatedSPDXElement: p.SPDXID,
+			})
+		}
+
+		doc.Packages = append(doc.Packages, p)
+	}
+
+	return doc
+}
+
+type cycloneDXDocument struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func (v *Version) cycloneDX(now time.Time) *cycloneDXDocument {
+	doc := &cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+	}
+
+	doc.Metadata.Timestamp = now.Format(time.RFC3339)
+	doc.Metadata.Tools.Components = []cycloneDXComponent{{Type: "application", Name: "clix"}}
+
+	pkgs := v.sbomPackages()
+	root := cycloneDXDependency{Ref: pkgs[0].id}
+
+	for i, pkg := range pkgs {
+		c := cycloneDXComponent{
+			Type:    "library",
+			BOMRef:  pkg.id,
+			Name:    pkg.path,
+			Version: pkg.version,
+			PURL:    pkg.purl(),
+		}
+
+		if pkg.sum != "" {
+			c.Properties = append(c.Properties, cycloneDXProperty{Name: "go:sum", Value: pkg.sum})
+		}
+		if pkg.replaces != nil {
+			c.Properties = append(c.Properties, cycloneDXProperty{
+				Name:  "go:replaces",
+				Value: strings.TrimSpace(pkg.replaces.Path + " " + pkg.replaces.Version),
+			})
+		}
+
+		switch i {
+		case 0:
+			c.Type = "application"
+			for _, s := range v.Settings {
+				c.Properties = append(c.Properties, cycloneDXProperty{Name: "go:build:" + s.Key, Value: s.Value})
+			}
+			doc.Metadata.Component = c
+			continue
+		case 1:
+			c.Type = "platform"
+		}
+
+		root.DependsOn = append(root.DependsOn, c.BOMRef)
+		doc.Components = append(doc.Components, c)
+	}
+
+	doc.Dependencies = append(doc.Dependencies, root)
+	return doc
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func testSBOMVersion() *Version {
+	return &Version{
+		mainPath:  "github.com/lrstanley/testapp",
+		AppInfo:   &AppInfo{Name: "testapp", Version: "v1.2.3", Commit: "abc"},
+		GoVersion: "go1.25.0",
+		OS:        "linux",
+		Arch:      "amd64",
+		Settings: []BuildSetting{
+			{Key: "CGO_ENABLED", Value: "0"},
+			{Key: "-trimpath", Value: "true"},
+		},
+		Dependencies: []Module{
+			{Path: "github.com/alecthomas/kong", Version: "v1.15.0", Sum: "h1:abc="},
+			{
+				Path:    "github.com/example/old",
+				Version: "v1.0.0",
+				Replace: &Module{Path: "github.com/example/new", Version: "v1.1.0", Sum: "h1:def="},
+			},
+		},
+	}
+}
+
+func TestWriteSBOMSPDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSBOMVersion().WriteSBOM(&buf, SBOMFormatSPDX); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc spdxDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || len(doc.Packages) != 4 || len(doc.Relationships) != 4 {
+		t.Fatalf("unexpected SPDX document: %s", buf.String())
+	}
+
+	main := doc.Packages[0]
+	if main.ExternalRefs[0].ReferenceLocator != "pkg:golang/github.com/lrstanley/testapp@v1.2.3" {
+		t.Fatalf("unexpected main package purl: %#v", main.ExternalRefs)
+	}
+	if main.Name != "github.com/lrstanley/testapp" {
+		t.Fatalf("expected main package to use the module path, got %q", main.Name)
+	}
+	if len(main.Annotations) != 2 || main.Annotations[0].Comment != "go.build.CGO_ENABLED=0" {
+		t.Fatalf("expected build settings as annotations, got %#v", main.Annotations)
+	}
+
+	if doc.Packages[1].Name != "stdlib" || doc.Packages[1].VersionInfo != "go1.25.0" {
+		t.Fatalf("expected toolchain package, got %#v", doc.Packages[1])
+	}
+
+	replaced := doc.Packages[3]
+	if replaced.Name != "github.com/example/new" || !strings.Contains(replaced.Comment, "replaces: github.com/example/old v1.0.0") {
+		t.Fatalf("expected replacement package, got %#v", replaced)
+	}
+
+	// Without build info, the application name isn't a valid purl.
+	version := testSBOMVersion()
+	version.mainPath = ""
+	if pkg := version.sbomPackages()[0]; pkg.path != "testapp" || pkg.purl() != "" {
+		t.Fatalf("expected main package without purl, got %#v", pkg)
+	}
+}
+
+func TestWriteSBOMCycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSBOMVersion().WriteSBOM(&buf, SBOMFormatCycloneDX); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc cycloneDXDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" || !strings.HasPrefix(doc.SerialNumber, "urn:uuid:") {
+		t.Fatalf("unexpected CycloneDX document: %s", buf.String())
+	}
+
+	if doc.Metadata.Component.Type != "application" || len(doc.Metadata.Component.Properties) != 2 {
+		t.Fatalf("expected main component with build settings, got %#v", doc.Metadata.Component)
+	}
+
+	if len(doc.Components) != 3 || doc.Components[0].Type != "platform" {
+		t.Fatalf("expected toolchain and dependency components, got %#v", doc.Components)
+	}
+
+	if len(doc.Dependencies) != 1 || len(doc.Dependencies[0].DependsOn) != 3 {
+		t.Fatalf("expected main component to depend on all components, got %#v", doc.Dependencies)
+	}
+
+	if err := testSBOMVersion().WriteSBOM(&buf, "invalid"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestSBOMCommand(t *testing.T) {
+	type Flags struct {
+		Required string `name:"required" required:""`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "sbom", "--format", "cyclonedx"}
+
+	var buf bytes.Buffer
+	code := -1
+
+	New(
+		WithKongOptions[Flags](
+			kong.Writers(&buf, &bytes.Buffer{}),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithAppInfo[Flags](AppInfo{Name: "testapp", Version: "v1.0.0"}),
+		WithSBOMPlugin[Flags](),
+	)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	// Parsing continues after the stubbed exit, so usage may follow the SBOM.
+	var doc cycloneDXDocument
+	if err := json.NewDecoder(&buf).Decode(&doc); err != nil {
+		t.Fatalf("expected CycloneDX JSON output: %v\n%s", err, buf.String())
+	}
+	// The main component is the main module, not the application name.
+	build, _ := debug.ReadBuildInfo()
+	if c := doc.Metadata.Component; c.Name != build.Main.Path || c.PURL != "pkg:golang/"+build.Main.Path+"@v1.0.0" {
+		t.Fatalf("expected main component to be %q, got %#v", build.Main.Path, c)
+	}
+}
@@ -0,0 +1,450 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+)
+
+// maxArtifactSize is the maximum size of a downloaded release artifact.
+const maxArtifactSize = 512 << 20
+
+// WithSelfUpdatePlugin adds a "self-update" command to the CLI, which updates the
+// running binary to the latest release from a release manifest. See [SelfUpdate]
+// for details. Like the markdown plugin, the command is invoked before kong
+// applies additional restrictions, so it ignores any other required flags.
+func WithSelfUpdatePlugin[T any](opts SelfUpdateOptions) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Swap(true) {
+			return
+		}
+
+		cli.kongOptions = append(
+			cli.kongOptions, kong.DynamicCommand(
+				"self-update",
+				"update to the latest release",
+				"",
+				&SelfUpdateCommand{opts: opts},
+			),
+		)
+	}
+}
+
+// SelfUpdateOptions configures [SelfUpdate] and [WithSelfUpdatePlugin].
+type SelfUpdateOptions struct {
+	// ManifestURL is the URL (or path) to a JSON release manifest, in the same
+	// format as [Release]. See [ManifestReleaseSource].
+	ManifestURL string
+
+	// PublicKey is an optional ed25519 public key. If provided, the release
+	// checksums file must have a valid signature (see [Release.Signature]).
+	PublicKey ed25519.PublicKey
+
+	// Client is the HTTP client to use. Defaults to [http.DefaultClient].
+	Client *http.Client
+
+	// Executable is the path to the binary to replace. Defaults to the running
+	// binary (see [os.Executable]).
+	Executable string
+
+	// Force updates even if the latest release isn't newer than the current
+	// version, or the current version isn't a valid semantic version.
+	Force bool
+}
+
+// SelfUpdateCommand is the command added by [WithSelfUpdatePlugin].
+type SelfUpdateCommand struct {
+	Check bool `name:"check" help:"only check if an update is available"`
+	Force bool `name:"force" help:"update even if already on the latest version"`
+
+	opts SelfUpdateOptions
+}
+
+func (c *SelfUpdateCommand) BeforeApply(kctx *kong.Context, path *kong.Path, version *Version) error {
+	opts := c.opts
+
+	var check bool
+	for _, flag := range path.Command.Flags {
+		switch flag.Name {
+		case "check":
+			check, _ = kctx.FlagValue(flag).(bool)
+		case "force":
+			opts.Force, _ = kctx.FlagValue(flag).(bool)
+		}
+	}
+
+	name := filepath.Base(version.AppInfo.Name)
+
+	if check {
+		release, err := (&ManifestReleaseSource{URL: opts.ManifestURL, Client: opts.Client}).LatestRelease(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
+
+		if newerRelease(version, release) == nil {
+			fmt.Fprintf(kctx.Stdout, "%s is up to date (%s)\n", name, version.AppInfo.Version)
+		} else {
+			printUpdateNotice(kctx.Stdout, version, release, false)
+		}
+		kctx.Exit(0)
+		return nil
+	}
+
+	release, err := SelfUpdate(context.Background(), version, opts)
+	if err != nil {
+		return err
+	}
+
+	if release == nil {
+		fmt.Fprintf(kctx.Stdout, "%s is up to date (%s)\n", name, version.AppInfo.Version)
+	} else {
+		fmt.Fprintf(kctx.Stdout, "updated %s from %s to %s\n", name, version.AppInfo.Version, release.Version)
+	}
+	kctx.Exit(0)
+	return nil
+}
+
+// SelfUpdate updates the binary to the latest release from the release manifest
+// in [SelfUpdateOptions.ManifestURL], returning the installed release, or nil if
+// already on the latest version.
+//
+// The artifact for the current [runtime.GOOS] and [runtime.GOARCH] is selected
+// using [Artifact.OS] and [Artifact.Arch] (falling back to artifact names
+// containing both), and can either be the raw binary, or a .tar.gz/.tgz/.zip
+// archive containing a file with the same name as the binary. The artifact is
+// verified against the SHA256 checksums file in [Release.Checksums] (or
+// [Artifact.SHA256] if the release has no checksums file), which itself must
+// be signed when [SelfUpdateOptions.PublicKey] is set.
+//
+// The binary is replaced atomically, and the original binary is restored if
+// the replacement fails.
+func SelfUpdate(ctx context.Context, current *Version, opts SelfUpdateOptions) (*Release, error) {
+	if opts.ManifestURL == "" {
+		return nil, errors.New("no release manifest URL configured")
+	}
+
+	if !opts.Force {
+		if _, err := current.SemVer(); err != nil {
+			return nil, fmt.Errorf("current version %q is not a release version, use force to update anyway", current.AppInfo.Version)
+		}
+	}
+
+	release, err := (&ManifestReleaseSource{URL: opts.ManifestURL, Client: opts.Client}).LatestRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.Force && newerRelease(current, release) == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	artifact, err := release.artifact(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := release.checksum(ctx, opts, artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fetchURL(ctx, opts.Client, artifact.URL, maxArtifactSize)
+	if errors.Is(err, errTooLarge) {
+		return nil, fmt.Errorf("artifact %q is too large: %w", artifact.Name, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact: %w", err)
+	}
+
+	if sum := sha256.Sum256(data); !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return nil, fmt.Errorf("checksum mismatch for artifact %q", artifact.Name)
+	}
+
+	exe := opts.Executable
+	if exe == "" {
+		if exe, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return nil, err
+	}
+
+	if data, err = extractBinary(artifact.Name, data, filepath.Base(exe)); err != nil {
+		return nil, err
+	}
+
+	if err = replaceExecutable(exe, data); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// artifact returns the artifact for the provided OS and architecture.
+func (r *Release) artifact(goos, goarch string) (*Artifact, error) {
+	for i := range r.Artifacts {
+		if r.Artifacts[i].OS == goos && r.Artifacts[i].Arch == goarch {
+			return &r.Artifacts[i], nil
+		}
+	}
+
+	// Fall back to matching the name, e.g. "app_1.2.3_linux_amd64.tar.gz". Tokens
+	// are compared exactly, so e.g. "arm" doesn't match "arm64".
+	for i := range r.Artifacts {
+		if r.Artifacts[i].OS != "" {
+			continue
+		}
+
+		tokens := strings.FieldsFunc(strings.ToLower(r.Artifacts[i].Name), func(c rune) bool {
+			return c == '_' || c == '-' || c == '.'
+		})
+		if slices.Contains(tokens, goos) && slices.Contains(tokens, goarch) {
+			return &r.Artifacts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no artifact found in release %s for %s/%s", r.Version, goos, goarch)
+}
+
+// checksum returns the expected hex-encoded SHA256 checksum of the artifact,
+// verifying the signature of the checksums file if required.
+func (r *Release) checksum(ctx context.Context, opts SelfUpdateOptions, artifact *Artifact) (string, error) {
+	if r.Checksums == "" {
+		if opts.PublicKey != nil {
+			return "", fmt.Errorf("release %s has no checksums file to verify the signature of", r.Version)
+		}
+		if artifact.SHA256 == "" {
+			return "", fmt.Errorf("release %s has no checksum for artifact %q", r.Version, artifact.Name)
+		}
+		return artifact.SHA256, nil
+	}
+
+	checksums, err := fetchURL(ctx, opts.Client, r.Checksums, maxReleaseResponseSize)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksums: %w", err)
+	}
+
+	if opts.PublicKey != nil {
+		if r.Signature == "" {
+			return "", fmt.Errorf("release %s has no checksums signature", r.Version)
+		}
+
+		var sig []byte
+		sig, err = fetchURL(ctx, opts.Client, r.Signature, maxReleaseResponseSize)
+		if err != nil {
+			return "", fmt.Errorf("failed to download checksums signature: %w", err)
+		}
+
+		if len(sig) != ed25519.SignatureSize {
+			sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+			if err != nil {
+				return "", fmt.Errorf("invalid checksums signature: %w", err)
+			}
+		}
+
+		if !ed25519.Verify(opts.PublicKey, checksums, sig) {
+			return "", errors.New("invalid checksums signature")
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == artifact.Name {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum found for artifact %q", artifact.Name)
+}
+
+// errTooLarge is returned when a response, file or archive entry exceeds its
+// maximum size.
+var errTooLarge = errors.New("too large")
+
+// isHTTPURL returns true if uri is an HTTP(S) URL.
+func isHTTPURL(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
+// readLimited reads r, returning [errTooLarge] if it is larger than limit,
+// rather than truncating it.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("%w (exceeds %d bytes)", errTooLarge, limit)
+	}
+	return b, nil
+}
+
+// fetchURL reads the contents of an HTTP(S) URL, "file://" URL, or local path.
+// Local paths must only be used when configured explicitly, not when provided
+// by a remote source (see [ManifestReleaseSource]).
+func fetchURL(ctx context.Context, client *http.Client, uri string, limit int64) ([]byte, error) {
+	if !isHTTPURL(uri) {
+		f, err := os.Open(strings.TrimPrefix(uri, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return readLimited(f, limit)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %s: %d", uri, resp.StatusCode)
+	}
+
+	return readLimited(resp.Body, limit)
+}
+
+// extractBinary returns the binary from the artifact, extracting it from the
+// archive if the artifact is an archive.
+func extractBinary(name string, data []byte, binary string) ([]byte, error) {
+	matches := func(fn string) bool {
+		return strings.TrimSuffix(filepath.Base(fn), ".exe") == strings.TrimSuffix(binary, ".exe")
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if hdr.Typeflag == tar.TypeReg && matches(hdr.Name) {
+				return readLimited(tr, maxArtifactSize)
+			}
+		}
+	case strings.HasSuffix(name, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !matches(f.Name) {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+
+			return readLimited(rc, maxArtifactSize)
+		}
+	default:
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("binary %q not found in artifact %q", binary, name)
+}
+
+// replaceExecutable atomically replaces the executable with the provided
+// contents, preserving its permissions, and restoring the original if the
+// replacement fails.
+func replaceExecutable(exe string, data []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	dir, base := filepath.Split(exe)
+
+	tmp, err := os.CreateTemp(dir, "."+base+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+
+	// Keep the original around until the new binary is in place, so it can be
+	// restored. Renaming (rather than overwriting) also works on Windows, where
+	// the running binary can't be written to.
+	backup := filepath.Join(dir, "."+base+".old")
+	_ = os.Remove(backup)
+
+	if err = os.Rename(exe, backup); err != nil {
+		return fmt.Errorf("failed to move original binary: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), exe); err != nil {
+		if rerr := os.Rename(backup, exe); rerr != nil {
+			return fmt.Errorf("failed to replace binary: %w (restoring original failed: %w)", err, rerr)
+		}
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+
+	// Removing the backup may fail on Windows while the original is running.
+	_ = os.Remove(backup)
+	return nil
+}
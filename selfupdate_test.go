@@ -0,0 +1,295 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+// newReleaseServer serves a release manifest, checksums, signature and the
+// provided artifact.
+func newReleaseServer(t *testing.T, name string, artifact []byte, key ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+
+	sum := sha256.Sum256(artifact)
+	checksums := []byte(fmt.Sprintf("%s  %s\n0000  other_artifact\n", hex.EncodeToString(sum[:]), name))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{
+			"version": "v1.1.0",
+			"checksums": "checksums.txt",
+			"signature": "checksums.txt.sig",
+			"artifacts": [{"name": %q, "url": %q, "os": %q, "arch": %q}]
+		}`, name, "/download/"+name, runtime.GOOS, runtime.GOARCH)
+	})
+	mux.HandleFunc("/checksums.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(checksums)
+	})
+	mux.HandleFunc("/checksums.txt.sig", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(ed25519.Sign(key, checksums))
+	})
+	mux.HandleFunc("/download/"+name, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(artifact)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeExecutable(t *testing.T) string {
+	t.Helper()
+
+	exe := filepath.Join(t.TempDir(), "testapp")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func TestSelfUpdate(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newReleaseServer(t, "testapp", []byte("new"), key)
+	version := &Version{AppInfo: &AppInfo{Name: "testapp", Version: "v1.0.0"}}
+
+	exe := writeExecutable(t)
+
+	release, err := SelfUpdate(t.Context(), version, SelfUpdateOptions{
+		ManifestURL: srv.URL + "/manifest.json",
+		PublicKey:   pub,
+		Executable:  exe,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release == nil || release.Version != "v1.1.0" {
+		t.Fatalf("expected release v1.1.0 to be installed, got %#v", release)
+	}
+
+	b, _ := os.ReadFile(exe)
+	if string(b) != "new" {
+		t.Fatalf("expected binary to be replaced, got %q", b)
+	}
+
+	info, _ := os.Stat(exe)
+	if info.Mode().Perm() != 0o755 {
+		t.Fatalf("expected permissions to be preserved, got %v", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary and backup files to be removed, got %d entries", len(entries))
+	}
+
+	// Already up to date.
+	version.AppInfo.Version = "v1.1.0"
+	if release, err = SelfUpdate(t.Context(), version, SelfUpdateOptions{
+		ManifestURL: srv.URL + "/manifest.json",
+		Executable:  exe,
+	}); err != nil || release != nil {
+		t.Fatalf("expected no update, got %#v, %v", release, err)
+	}
+}
+
+func TestSelfUpdateVerification(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newReleaseServer(t, "testapp", []byte("new"), key)
+	version := &Version{AppInfo: &AppInfo{Name: "testapp", Version: "v1.0.0"}}
+
+	// Wrong public key.
+	exe := writeExecutable(t)
+	_, err = SelfUpdate(t.Context(), version, SelfUpdateOptions{
+		ManifestURL: srv.URL + "/manifest.json",
+		PublicKey:   otherPub,
+		Executable:  exe,
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid checksums signature") {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+
+	// Checksum mismatch (artifact doesn't match the served checksums).
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"version": "v1.1.0", "artifacts": [{"name": "testapp_%s_%s", "url": "bin", "sha256": "abcd"}]}`, runtime.GOOS, runtime.GOARCH)
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("tampered"))
+	})
+	bad := httptest.NewServer(mux)
+	defer bad.Close()
+
+	_, err = SelfUpdate(t.Context(), version, SelfUpdateOptions{ManifestURL: bad.URL + "/manifest.json", Executable: exe})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+
+	b, _ := os.ReadFile(exe)
+	if string(b) != "old" {
+		t.Fatalf("expected original binary to be untouched, got %q", b)
+	}
+
+	// Development builds require force.
+	version.AppInfo.Version = "(devel)"
+	if _, err = SelfUpdate(t.Context(), version, SelfUpdateOptions{ManifestURL: srv.URL + "/manifest.json", Executable: exe}); err == nil {
+		t.Fatal("expected error updating development build without force")
+	}
+}
+
+func TestReleaseArtifact(t *testing.T) {
+	release := &Release{
+		Version: "v1.1.0",
+		Artifacts: []Artifact{
+			{Name: "testapp_1.1.0_linux_arm64.tar.gz"},
+			{Name: "testapp_1.1.0_linux_amd64v3.tar.gz"},
+			{Name: "testapp-1.1.0-linux-arm.tar.gz"},
+			{Name: "testapp_1.1.0_linux_amd64.tar.gz"},
+			{Name: "testapp.exe", OS: "windows", Arch: "amd64"},
+		},
+	}
+
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{goos: "linux", goarch: "arm", want: "testapp-1.1.0-linux-arm.tar.gz"},
+		{goos: "linux", goarch: "arm64", want: "testapp_1.1.0_linux_arm64.tar.gz"},
+		{goos: "linux", goarch: "amd64", want: "testapp_1.1.0_linux_amd64.tar.gz"},
+		{goos: "windows", goarch: "amd64", want: "testapp.exe"},
+		{goos: "darwin", goarch: "arm64"},
+	}
+
+	for _, tt := range tests {
+		artifact, err := release.artifact(tt.goos, tt.goarch)
+		if tt.want == "" {
+			if err == nil {
+				t.Fatalf("expected no artifact for %s/%s, got %q", tt.goos, tt.goarch, artifact.Name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if artifact.Name != tt.want {
+			t.Fatalf("expected artifact %q for %s/%s, got %q", tt.want, tt.goos, tt.goarch, artifact.Name)
+		}
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, f := range []struct{ name, body string }{
+		{"README.md", "readme"},
+		{"testapp_v1.1.0/testapp", "binary"},
+	} {
+		_ = tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.body)), Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte(f.body))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+
+	b, err := extractBinary("testapp.tar.gz", buf.Bytes(), "testapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "binary" {
+		t.Fatalf("expected binary contents, got %q", b)
+	}
+
+	if _, err = extractBinary("testapp.tar.gz", buf.Bytes(), "other"); err == nil {
+		t.Fatal("expected error for missing binary")
+	}
+}
+
+func TestFetchURLLimit(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(fn, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := fetchURL(t.Context(), nil, fn, 10); err != nil || string(b) != "0123456789" {
+		t.Fatalf("expected full contents, got %q (%v)", b, err)
+	}
+
+	// Responses larger than the limit shouldn't be silently truncated.
+	if _, err := fetchURL(t.Context(), nil, "file://"+fn, 5); !errors.Is(err, errTooLarge) {
+		t.Fatalf("expected too large error, got %v", err)
+	}
+}
+
+func TestSelfUpdateCommand(t *testing.T) {
+	type Flags struct {
+		Required string `name:"required" required:""`
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newReleaseServer(t, "testapp", []byte("new"), key)
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "self-update", "--check"}
+
+	var buf strings.Builder
+	code := -1
+
+	New(
+		WithKongOptions[Flags](
+			kong.Writers(&buf, &buf),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithAppInfo[Flags](AppInfo{Name: "testapp", Version: "v1.0.0"}),
+		WithSelfUpdatePlugin[Flags](SelfUpdateOptions{ManifestURL: srv.URL + "/manifest.json"}),
+	)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, buf.String())
+	}
+
+	if !strings.Contains(buf.String(), "v1.0.0 -> v1.1.0") {
+		t.Fatalf("expected update notice, got:\n%s", buf.String())
+	}
+}
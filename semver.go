@@ -0,0 +1,268 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+)
+
+// ExitCodeVersionTooOld is the exit code used when the binary is older than the
+// minimum supported version. See [WithMinimumVersion].
+const ExitCodeVersionTooOld = 3
+
+var (
+	reSemVer = regexp.MustCompile(
+		`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+			`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+			`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`,
+	)
+
+	// rePseudoVersion matches the pre-release portion of Go pseudo-versions,
+	// e.g. "0.20240101120000-abcdef123456" or "20240101120000-abcdef123456".
+	rePseudoVersion = regexp.MustCompile(`(?:^|\.)\d{14}-[0-9a-f]{12}$`)
+)
+
+// SemVer is a parsed semantic version (https://semver.org), with an optional
+// "v" prefix.
+type SemVer struct {
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	Prerelease string `json:"prerelease,omitempty"` // Pre-release, e.g. "rc.1".
+	Build      string `json:"build,omitempty"`      // Build metadata, e.g. "dirty".
+}
+
+// ParseSemVer parses a semantic version, e.g. "v1.2.3", "1.2.3-rc.1" or
+// "v1.2.3-0.20240101120000-abcdef123456+dirty".
+func ParseSemVer(s string) (SemVer, error) {
+	m := reSemVer.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return SemVer{}, fmt.Errorf("invalid semantic version: %q", s)
+	}
+
+	var v SemVer
+	var err error
+
+	if v.Major, err = strconv.Atoi(m[1]); err != nil {
+		return SemVer{}, err
+	}
+	if v.Minor, err = strconv.Atoi(m[2]); err != nil {
+		return SemVer{}, err
+	}
+	if v.Patch, err = strconv.Atoi(m[3]); err != nil {
+		return SemVer{}, err
+	}
+
+	v.Prerelease = m[4]
+	v.Build = m[5]
+	return v, nil
+}
+
+func (v SemVer) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on if v is less than, equal to, or
+// greater than o, using semver precedence rules (build metadata is ignored).
+func (v SemVer) Compare(o SemVer) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// A version without a pre-release has higher precedence.
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a := strings.Split(v.Prerelease, ".")
+	b := strings.Split(o.Prerelease, ".")
+
+	for i := range min(len(a), len(b)) {
+		ai, aErr := strconv.Atoi(a[i])
+		bi, bErr := strconv.Atoi(b[i])
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(ai, bi)
+		case aErr == nil:
+			c = -1 // Numeric identifiers have lower precedence.
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
+
+// LessThan returns true if v has lower precedence than o.
+func (v SemVer) LessThan(o SemVer) bool {
+	return v.Compare(o) < 0
+}
+
+// IsPseudo returns true if the version is a Go pseudo-version (e.g.
+// "v0.0.0-20240101120000-abcdef123456").
+func (v SemVer) IsPseudo() bool {
+	return rePseudoVersion.MatchString(v.Prerelease)
+}
+
+// IsPrerelease returns true if the version has a pre-release component, which
+// includes pseudo-versions.
+func (v SemVer) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// SemVer returns the parsed semantic version of [AppInfo.Version], or an error if
+// it is not a valid semantic version (e.g. "(devel)" or "unknown").
+func (v *Version) SemVer() (SemVer, error) {
+	return ParseSemVer(v.AppInfo.Version)
+}
+
+// IsPseudo returns true if the application version is a Go pseudo-version,
+// which is generally the case for untagged builds.
+func (v *Version) IsPseudo() bool {
+	sv, err := v.SemVer()
+	return err == nil && sv.IsPseudo()
+}
+
+// IsDirty returns true if the binary was built from a VCS checkout with
+// uncommitted changes, based on the "vcs.modified" build setting, or "+dirty"
+// build metadata in the version.
+func (v *Version) IsDirty() bool {
+	if v.GetSetting("vcs.modified", "false") == "true" {
+		return true
+	}
+
+	sv, err := v.SemVer()
+	return err == nil && strings.Contains("."+sv.Build+".", ".dirty.")
+}
+
+// Compare compares the application version against the provided version. See
+// [SemVer.Compare]. Returns an error if either version is not a valid semantic
+// version.
+func (v *Version) Compare(other string) (int, error) {
+	sv, err := v.SemVer()
+	if err != nil {
+		return 0, err
+	}
+
+	ov, err := ParseSemVer(other)
+	if err != nil {
+		return 0, err
+	}
+
+	return sv.Compare(ov), nil
+}
+
+// AtLeast returns true if the application version is greater than or equal to
+// the provided version. Returns an error if either version is not a valid semantic
+// version.
+func (v *Version) AtLeast(minimum string) (bool, error) {
+	c, err := v.Compare(minimum)
+	return c >= 0, err
+}
+
+// WithMinimumVersion refuses to run the CLI (exiting with [ExitCodeVersionTooOld])
+// when the binary is older than the minimum supported version. The minimum
+// version is read from the provided environment variable, or if not set, from
+// the first line of the provided file path. Either can be empty to disable
+// that source, and if neither provide a version, no check is done.
+//
+// Builds without a valid semantic version (e.g. "(devel)" or "unknown") are not
+// checked. Help and version flags are still allowed when the binary is too old.
+func WithMinimumVersion[T any](envVar, path string) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+		cli.kongOptions = append(cli.kongOptions, kong.WithAfterApply(func(kctx *kong.Context) error {
+			if initialized.Swap(true) {
+				return nil
+			}
+
+			minimum, source, err := readMinimumVersion(envVar, path)
+			if err != nil || minimum == "" {
+				return err
+			}
+
+			if _, err = cli.version.SemVer(); err != nil {
+				return nil //nolint:nilerr
+			}
+
+			ok, err := cli.version.AtLeast(minimum)
+			if err != nil {
+				return fmt.Errorf("invalid minimum version from %s: %w", source, err)
+			}
+
+			if !ok {
+				kctx.Errorf(
+					"version %s is older than the minimum supported version %s (from %s), please upgrade",
+					cli.version.AppInfo.Version, minimum, source,
+				)
+				kctx.Exit(ExitCodeVersionTooOld)
+			}
+			return nil
+		}))
+	}
+}
+
+// readMinimumVersion reads the minimum version from the environment variable, or
+// the file, returning the version and a description of where it came from.
+func readMinimumVersion(envVar, path string) (version, source string, err error) {
+	if envVar != "" {
+		if v := strings.TrimSpace(os.Getenv(envVar)); v != "" {
+			return v, "$" + envVar, nil
+		}
+	}
+
+	if path == "" {
+		return "", "", nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to read minimum version: %w", err)
+	}
+
+	version, _, _ = strings.Cut(strings.TrimSpace(string(b)), "\n")
+	return strings.TrimSpace(version), path, nil
+}
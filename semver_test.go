@@ -0,0 +1,130 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestSemVerCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"v1.0.0+build.1", "v1.0.0+build.2", 0},
+	}
+
+	for _, tt := range tests {
+		a, err := ParseSemVer(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseSemVer(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := a.Compare(b); got != tt.want {
+			t.Fatalf("expected %s compared to %s to be %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	for _, s := range []string{"(devel)", "unknown", "1.2", "v1.2.3.4", "v01.2.3"} {
+		if _, err := ParseSemVer(s); err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestVersionPseudoAndDirty(t *testing.T) {
+	v := &Version{AppInfo: &AppInfo{Version: "v0.0.0-20240101120000-abcdef123456+dirty"}}
+	if !v.IsPseudo() {
+		t.Fatal("expected pseudo-version")
+	}
+	if !v.IsDirty() {
+		t.Fatal("expected dirty version from build metadata")
+	}
+
+	v = &Version{
+		AppInfo:  &AppInfo{Version: "v1.2.3"},
+		Settings: []BuildSetting{{Key: "vcs.modified", Value: "true"}},
+	}
+	if v.IsPseudo() {
+		t.Fatal("expected non-pseudo version")
+	}
+	if !v.IsDirty() {
+		t.Fatal("expected dirty version from vcs.modified")
+	}
+}
+
+func TestWithMinimumVersion(t *testing.T) {
+	type Flags struct{}
+
+	fn := filepath.Join(t.TempDir(), "min-version")
+	if err := os.WriteFile(fn, []byte("v1.5.0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version string
+		env     string
+		want    int
+	}{
+		{version: "v1.4.9", want: ExitCodeVersionTooOld},
+		{version: "v1.5.0", want: 0},
+		{version: "v1.5.0", env: "v2.0.0", want: ExitCodeVersionTooOld},
+		{version: "(devel)", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"-"+tt.env, func(t *testing.T) {
+			t.Setenv("CLIX_MIN_VERSION", tt.env)
+
+			oldArgs := os.Args
+			t.Cleanup(func() { os.Args = oldArgs })
+			os.Args = []string{"testapp"}
+
+			var buf strings.Builder
+			code := -1
+
+			New(
+				WithKongOptions[Flags](
+					kong.Writers(&buf, &buf),
+					kong.Exit(func(c int) { code = c }),
+				),
+				WithAppInfo[Flags](AppInfo{Version: tt.version}),
+				WithMinimumVersion[Flags]("CLIX_MIN_VERSION", fn),
+			)
+
+			if tt.want == 0 {
+				if code != -1 {
+					t.Fatalf("expected no exit, got exit code %d: %s", code, buf.String())
+				}
+				return
+			}
+
+			if code != tt.want {
+				t.Fatalf("expected exit code %d, got %d", tt.want, code)
+			}
+
+			if !strings.Contains(buf.String(), "older than the minimum supported version") {
+				t.Fatalf("expected minimum version error, got:\n%s", buf.String())
+			}
+		})
+	}
+}
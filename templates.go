@@ -102,17 +102,12 @@ var (
 			}
 			return results
 		},
-		"slug": func(input any) string {
-			re := regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
-			slug := fmt.Sprintf("%v", input)
-			slug = strings.ToLower(slug)
-			slug = strings.TrimSpace(slug)
-			slug = re.ReplaceAllString(slug, "-")
-			slug = strings.ReplaceAll(slug, "--", "-")
-			slug = strings.Trim(slug, "-")
-			return slug
-		},
-		"sanitize_md": sanizeMarkdown,
+		"examples":     NodeExamples,
+		"deprecations": Deprecations,
+		"flag_spec":    newFlagSpec,
+		"node_args":    nodeArgs,
+		"slug":         slugify,
+		"sanitize_md":  sanizeMarkdown,
 		"quote_code": func(input any) any {
 			return applyStringFn(input, func(s string) string {
 				if s == "" {
@@ -273,6 +268,19 @@ var (
 	}
 )
 
+var reSlug = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// slugify converts the input into a slug, usable for anchors and file names.
+func slugify(input any) string {
+	slug := fmt.Sprintf("%v", input)
+	slug = strings.ToLower(slug)
+	slug = strings.TrimSpace(slug)
+	slug = reSlug.ReplaceAllString(slug, "-")
+	slug = strings.ReplaceAll(slug, "--", "-")
+	slug = strings.Trim(slug, "-")
+	return slug
+}
+
 func reflectBool(input any) bool {
 	rv := reflect.ValueOf(input)
 
@@ -351,3 +359,15 @@ func sanizeMarkdown(s any) string {
 	)
 	return replacer.Replace(fmt.Sprintf("%v", s))
 }
+
+// nodeArgs returns the positional arguments of the node, including branching
+// arguments (`arg:""` on a struct with child commands).
+func nodeArgs(node *kong.Node) []*kong.Value {
+	results := slices.Clone(node.Positional)
+	for _, child := range node.Children {
+		if child.Type == kong.ArgumentNode && child.Argument != nil {
+			results = append(results, child.Argument)
+		}
+	}
+	return results
+}
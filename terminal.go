@@ -0,0 +1,223 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"golang.org/x/term"
+)
+
+// defaultTerminalWidth is the width used when the terminal width cannot be
+// determined.
+const defaultTerminalWidth = 80
+
+// ColorMode controls when colors are used in output.
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"   // Use colors when outputting to a TTY, respecting env vars.
+	ColorAlways ColorMode = "always" // Always use colors.
+	ColorNever  ColorMode = "never"  // Never use colors.
+)
+
+// ColorProfile is the color support level of a terminal.
+type ColorProfile int
+
+const (
+	ColorNone      ColorProfile = iota // No color support.
+	ColorBasic                         // 16 colors.
+	Color256                           // 256 colors.
+	ColorTrueColor                     // 24-bit colors.
+)
+
+func (p ColorProfile) String() string {
+	switch p {
+	case ColorNone:
+		return "none"
+	case ColorBasic:
+		return "basic"
+	case Color256:
+		return "256"
+	case ColorTrueColor:
+		return "truecolor"
+	default:
+		return "unknown"
+	}
+}
+
+// Term returns the terminal information for stdout/stderr, respecting the
+// --color flag. Before parsing, [ColorAuto] is used.
+func (cli *CLI[T]) Term() *Terminal {
+	return NewTerminal(ColorMode(cli.Color))
+}
+
+// Terminal provides TTY detection, color profile detection and terminal width for
+// stdout and stderr. Obtain one via [CLI.Term] or [NewTerminal].
+type Terminal struct {
+	mode   ColorMode
+	stdout *os.File
+	stderr *os.File
+}
+
+// NewTerminal returns a new [Terminal] for stdout and stderr, using the provided
+// color mode. An empty mode is treated as [ColorAuto].
+func NewTerminal(mode ColorMode) *Terminal {
+	if mode == "" {
+		mode = ColorAuto
+	}
+
+	return &Terminal{
+		mode:   mode,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// terminalFromContext returns a [Terminal] using the --color flag from the kong
+// context, which can be used before values are applied (e.g. in BeforeReset
+// hooks).
+func terminalFromContext(kctx *kong.Context) *Terminal {
+	for _, flag := range kctx.Flags() {
+		if flag.Name == "color" {
+			if v, ok := kctx.FlagValue(flag).(string); ok {
+				return NewTerminal(ColorMode(v))
+			}
+		}
+	}
+	return NewTerminal(ColorAuto)
+}
+
+// Mode returns the configured color mode.
+func (t *Terminal) Mode() ColorMode {
+	return t.mode
+}
+
+// StdoutTTY returns true if stdout is a TTY.
+func (t *Terminal) StdoutTTY() bool {
+	return isTTY(t.stdout)
+}
+
+// StderrTTY returns true if stderr is a TTY.
+func (t *Terminal) StderrTTY() bool {
+	return isTTY(t.stderr)
+}
+
+// StdoutProfile returns the color profile for stdout.
+func (t *Terminal) StdoutProfile() ColorProfile {
+	return t.profile(t.StdoutTTY())
+}
+
+// StderrProfile returns the color profile for stderr.
+func (t *Terminal) StderrProfile() ColorProfile {
+	return t.profile(t.StderrTTY())
+}
+
+// StdoutColor returns true if colors should be used when writing to stdout.
+func (t *Terminal) StdoutColor() bool {
+	return t.StdoutProfile() > ColorNone
+}
+
+// StderrColor returns true if colors should be used when writing to stderr.
+func (t *Terminal) StderrColor() bool {
+	return t.StderrProfile() > ColorNone
+}
+
+// Width returns the width of the terminal (stdout, falling back to stderr). If
+// neither are a TTY, the COLUMNS environment variable is used, otherwise
+// defaults to 80.
+func (t *Terminal) Width() int {
+	for _, f := range []*os.File{t.stdout, t.stderr} {
+		if !isTTY(f) {
+			continue
+		}
+		if w, _, err := term.GetSize(int(f.Fd())); err == nil && w > 0 { //nolint:gosec
+			return w
+		}
+	}
+
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+
+	return defaultTerminalWidth
+}
+
+// profile returns the color profile, based on the color mode, if the output is
+// a TTY, and the following environment variables:
+//
+//   - NO_COLOR: if set to any non-empty value, disables colors.
+//   - FORCE_COLOR / CLICOLOR_FORCE: if set to a non-empty value (other than "0"
+//     or "false"), enables colors even when not a TTY.
+//   - TERM: if "dumb", disables colors. Also used to detect 256-color support.
+//   - CLICOLOR: if "0", disables colors.
+//   - COLORTERM: if "truecolor" or "24bit", enables 24-bit colors.
+func (t *Terminal) profile(tty bool) ColorProfile {
+	switch t.mode {
+	case ColorNever:
+		return ColorNone
+	case ColorAlways:
+		return detectColorProfile()
+	case ColorAuto:
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorNone
+	}
+
+	for _, key := range []string{"FORCE_COLOR", "CLICOLOR_FORCE"} {
+		v, ok := os.LookupEnv(key)
+		if !ok || v == "" {
+			continue
+		}
+		if v == "0" || strings.EqualFold(v, "false") {
+			return ColorNone
+		}
+		return detectColorProfile()
+	}
+
+	if !tty || os.Getenv("TERM") == "dumb" || os.Getenv("CLICOLOR") == "0" {
+		return ColorNone
+	}
+
+	return detectColorProfile()
+}
+
+func detectColorProfile() ColorProfile {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorTrueColor
+	}
+
+	termEnv := strings.ToLower(os.Getenv("TERM"))
+
+	switch {
+	case strings.Contains(termEnv, "truecolor"), strings.Contains(termEnv, "direct"):
+		return ColorTrueColor
+	case strings.Contains(termEnv, "256color"):
+		return Color256
+	default:
+		return ColorBasic
+	}
+}
+
+func isTTY(f *os.File) bool {
+	return f != nil && term.IsTerminal(int(f.Fd())) //nolint:gosec
+}
+
+var reHeading = regexp.MustCompile(`(?m)^([A-Za-z][A-Za-z0-9 _-]*:)$`)
+
+// styleHeadings bolds any lines which look like section headings (e.g. "Flags:"),
+// if color is enabled.
+func styleHeadings(s string, color bool) string {
+	if !color {
+		return s
+	}
+	return reHeading.ReplaceAllString(s, "\x1b[1m$1\x1b[0m")
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestTerminalProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ColorMode
+		tty  bool
+		env  map[string]string
+		want ColorProfile
+	}{
+		{name: "auto-no-tty", mode: ColorAuto, want: ColorNone},
+		{name: "auto-tty", mode: ColorAuto, tty: true, want: ColorBasic},
+		{name: "auto-tty-256", mode: ColorAuto, tty: true, env: map[string]string{"TERM": "xterm-256color"}, want: Color256},
+		{name: "auto-tty-truecolor", mode: ColorAuto, tty: true, env: map[string]string{"COLORTERM": "truecolor"}, want: ColorTrueColor},
+		{name: "auto-no-color-yes", mode: ColorAuto, tty: true, env: map[string]string{"NO_COLOR": "yes"}, want: ColorNone},
+		{name: "auto-dumb", mode: ColorAuto, tty: true, env: map[string]string{"TERM": "dumb"}, want: ColorNone},
+		{name: "auto-clicolor-0", mode: ColorAuto, tty: true, env: map[string]string{"CLICOLOR": "0"}, want: ColorNone},
+		{name: "auto-force-color", mode: ColorAuto, env: map[string]string{"FORCE_COLOR": "1"}, want: ColorBasic},
+		{name: "auto-force-color-0", mode: ColorAuto, tty: true, env: map[string]string{"FORCE_COLOR": "0"}, want: ColorNone},
+		{name: "always", mode: ColorAlways, env: map[string]string{"NO_COLOR": "1"}, want: ColorBasic},
+		{name: "never", mode: ColorNever, tty: true, want: ColorNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "CLICOLOR", "COLORTERM"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("TERM", "xterm")
+
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			if got := NewTerminal(tt.mode).profile(tt.tty); got != tt.want {
+				t.Fatalf("expected profile %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStyleHeadings(t *testing.T) {
+	in := "Flags:\n  -h, --help    Show help.\n"
+
+	if got := styleHeadings(in, false); got != in {
+		t.Fatalf("expected no styling without color, got %q", got)
+	}
+
+	if got := styleHeadings(in, true); got != "\x1b[1mFlags:\x1b[0m\n  -h, --help    Show help.\n" {
+		t.Fatalf("unexpected styled output: %q", got)
+	}
+}
+
+func TestHelpPrinterWidth(t *testing.T) {
+	type Flags struct {
+		Name string `name:"name" help:"a flag with a long description, which should be wrapped to the width of the terminal, rather than overflowing it"`
+	}
+
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("COLUMNS", "")
+	_ = os.Unsetenv("COLUMNS")
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "--help"}
+
+	var buf strings.Builder
+	New(WithKongOptions[Flags](
+		kong.Writers(&buf, &buf),
+		kong.Exit(func(int) {}),
+	))
+
+	if !strings.Contains(buf.String(), "\x1b[1m") {
+		t.Fatalf("expected styled help output, got:\n%s", buf.String())
+	}
+
+	for line := range strings.SplitSeq(buf.String(), "\n") {
+		if len(line) > defaultTerminalWidth && !strings.Contains(line, "\x1b[") {
+			t.Fatalf("expected help to be wrapped to %d columns, got %q", defaultTerminalWidth, line)
+		}
+	}
+
+	if _, ok := os.LookupEnv("COLUMNS"); ok {
+		t.Fatal("expected COLUMNS to not be set by the help printer")
+	}
+}
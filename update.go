@@ -0,0 +1,501 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/kong"
+)
+
+const (
+	// DefaultUpdateCheckTTL is the default duration update check results are
+	// cached for.
+	DefaultUpdateCheckTTL = 24 * time.Hour
+
+	// DefaultUpdateCheckTimeout is the default timeout when fetching the latest
+	// release.
+	DefaultUpdateCheckTimeout = 5 * time.Second
+
+	// DefaultUpdateNoticeTimeout is the default timeout when [WithUpdateCheck]
+	// refreshes the cached latest release.
+	DefaultUpdateNoticeTimeout = 1 * time.Second
+
+	// DefaultUpdateCheckDisableEnv is the default environment variable which
+	// disables update checks.
+	DefaultUpdateCheckDisableEnv = "NO_UPDATE_CHECK"
+
+	// maxReleaseResponseSize is the maximum size of a release response body.
+	maxReleaseResponseSize = 10 << 20
+)
+
+// Release describes a release of the application.
+type Release struct {
+	Version     string     `json:"version"`               // Release version, e.g. "v1.2.3".
+	URL         string     `json:"url,omitempty"`         // URL to the release page/notes.
+	PublishedAt time.Time  `json:"published_at,omitzero"` // When the release was published.
+	Notes       string     `json:"notes,omitempty"`       // Release notes.
+	Artifacts   []Artifact `json:"artifacts,omitempty"`   // Downloadable artifacts, if supported by the source.
+	Checksums   string     `json:"checksums,omitempty"`   // URL to a SHA256 checksums file (sha256sum format), see [SelfUpdate].
+	Signature   string     `json:"signature,omitempty"`   // URL to an ed25519 signature of the checksums file, see [SelfUpdate].
+}
+
+// Artifact is a downloadable artifact of a [Release].
+type Artifact struct {
+	Name   string `json:"name"`             // Name of the artifact, e.g. "app_linux_amd64.tar.gz".
+	URL    string `json:"url"`              // URL to download the artifact from.
+	OS     string `json:"os,omitempty"`     // GOOS the artifact is for, if applicable.
+	Arch   string `json:"arch,omitempty"`   // GOARCH the artifact is for, if applicable.
+	SHA256 string `json:"sha256,omitempty"` // Hex-encoded SHA256 checksum of the artifact.
+}
+
+// ReleaseSource is a source of releases, used by [WithUpdateCheck].
+type ReleaseSource interface {
+	// LatestRelease returns the latest release.
+	LatestRelease(ctx context.Context) (*Release, error)
+}
+
+// GithubReleaseSource is a [ReleaseSource] which uses the latest release of a
+// GitHub repository.
+type GithubReleaseSource struct {
+	// Repo is the repository, in the format "owner/repo" (or a GitHub URL, e.g.
+	// "https://github.com/owner/repo").
+	Repo string
+
+	// BaseURL is the GitHub API base URL. Defaults to "https://api.github.com".
+	BaseURL string
+
+	// Client is the HTTP client to use. Defaults to [http.DefaultClient].
+	Client *http.Client
+}
+
+// LatestRelease implements [ReleaseSource].
+func (s *GithubReleaseSource) LatestRelease(ctx context.Context) (*Release, error) {
+	repo := strings.TrimSuffix(s.Repo, "/")
+	repo = strings.TrimPrefix(repo, "https://")
+	repo = strings.TrimPrefix(repo, "github.com/")
+
+	if strings.Count(repo, "/") != 1 {
+		return nil, fmt.Errorf("invalid github repository: %q", s.Repo)
+	}
+
+	base := s.BaseURL
+	if base == "" {
+		base = "https://api.github.com"
+	}
+
+	var resp struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+		Assets      []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+
+	err := fetchJSON(ctx, s.Client, strings.TrimSuffix(base, "/")+"/repos/"+repo+"/releases/latest", &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{
+		Version:     resp.TagName,
+		URL:         resp.HTMLURL,
+		Notes:       resp.Body,
+		PublishedAt: resp.PublishedAt,
+	}
+
+	for _, asset := range resp.Assets {
+		release.Artifacts = append(release.Artifacts, Artifact{
+			Name: asset.Name,
+			URL:  asset.BrowserDownloadURL,
+		})
+	}
+
+	return release, nil
+}
+
+// ManifestReleaseSource is a [ReleaseSource] which reads a JSON release manifest
+// (in the same format as [Release]), from either an HTTP(S) URL, a "file://" URL,
+// or a local path. This is useful for internally hosted releases. Manifests read
+// from an HTTP(S) URL may only reference HTTP(S) URLs, so they can't point to
+// local files.
+type ManifestReleaseSource struct {
+	// URL is the URL or path to the manifest.
+	URL string
+
+	// Client is the HTTP client to use. Defaults to [http.DefaultClient].
+	Client *http.Client
+}
+
+// LatestRelease implements [ReleaseSource].
+func (s *ManifestReleaseSource) LatestRelease(ctx context.Context) (*Release, error) {
+	b, err := fetchURL(ctx, s.Client, s.URL, maxReleaseResponseSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release manifest: %w", err)
+	}
+
+	release := &Release{}
+	if err = json.Unmarshal(b, release); err != nil {
+		return nil, fmt.Errorf("failed to decode release manifest: %w", err)
+	}
+
+	if release.Version == "" {
+		return nil, errors.New("release manifest is missing version")
+	}
+
+	// Resolve relative URLs against the manifest URL.
+	if base, err := url.Parse(s.URL); err == nil && base.Scheme != "" {
+		resolve := func(ref *string) {
+			if u, err := base.Parse(*ref); *ref != "" && err == nil {
+				*ref = u.String()
+			}
+		}
+
+		for i := range release.Artifacts {
+			resolve(&release.Artifacts[i].URL)
+		}
+		resolve(&release.Checksums)
+		resolve(&release.Signature)
+	}
+
+	if isHTTPURL(s.URL) {
+		refs := []string{release.Checksums, release.Signature}
+		for _, a := range release.Artifacts {
+			refs = append(refs, a.URL)
+		}
+
+		for _, ref := range refs {
+			if ref != "" && !isHTTPURL(ref) {
+				return nil, fmt.Errorf("release manifest references non-HTTP(S) URL %q", ref)
+			}
+		}
+	}
+
+	return release, nil
+}
+
+func fetchJSON(ctx context.Context, client *http.Client, uri string, v any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from %s: %d", uri, resp.StatusCode)
+	}
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxReleaseResponseSize)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", uri, err)
+	}
+	return nil
+}
+
+// UpdateCheckOptions configures [WithUpdateCheck] and [CheckForUpdate].
+type UpdateCheckOptions struct {
+	// Source is the source of releases. If nil, defaults to a [GithubReleaseSource]
+	// using the "github" link from [AppInfo.Links] (see [GithubLinks]).
+	Source ReleaseSource
+
+	// CacheFile is where the latest release is cached. Defaults to
+	// "<user cache dir>/<app>/update-check.json".
+	CacheFile string
+
+	// CacheTTL is how long the latest release is cached for. Defaults to
+	// [DefaultUpdateCheckTTL].
+	CacheTTL time.Duration
+
+	// Timeout is the timeout when fetching the latest release. Defaults to
+	// [DefaultUpdateCheckTimeout].
+	Timeout time.Duration
+
+	// NoticeTimeout is the (shorter) timeout used by [WithUpdateCheck] when
+	// refreshing the cached latest release, so the CLI isn't slowed down. Defaults
+	// to [DefaultUpdateNoticeTimeout].
+	NoticeTimeout time.Duration
+
+	// DisableEnv is the environment variable which, when set to any value other
+	// than a false value (e.g. "0" or "false"), disables update checks. Defaults
+	// to [DefaultUpdateCheckDisableEnv].
+	DisableEnv string
+}
+
+func (o *UpdateCheckOptions) setDefaults(app *AppInfo) {
+	if o.Source == nil && app != nil {
+		for _, link := range app.Links {
+			if link.Name == "github" {
+				o.Source = &GithubReleaseSource{Repo: link.URL}
+				break
+			}
+		}
+	}
+
+	if o.CacheFile == "" && app != nil {
+		if dir, err := os.UserCacheDir(); err == nil {
+			o.CacheFile = filepath.Join(dir, filepath.Base(app.Name), "update-check.json")
+		}
+	}
+
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = DefaultUpdateCheckTTL
+	}
+
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultUpdateCheckTimeout
+	}
+
+	if o.NoticeTimeout <= 0 {
+		o.NoticeTimeout = DefaultUpdateNoticeTimeout
+	}
+
+	if o.DisableEnv == "" {
+		o.DisableEnv = DefaultUpdateCheckDisableEnv
+	}
+}
+
+// disabled returns true if update checks are disabled through the environment,
+// or when running in CI. Any non-empty value other than a false value (e.g.
+// "yes" or "on") disables them.
+func (o *UpdateCheckOptions) disabled() bool {
+	for _, key := range []string{o.DisableEnv, "CI"} {
+		v := os.Getenv(key)
+		if v == "" {
+			continue
+		}
+
+		if enabled, err := strconv.ParseBool(v); err != nil || enabled {
+			return true
+		}
+	}
+	return false
+}
+
+type updateCache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Release   *Release  `json:"release"`
+
+	// FailedAt is when refreshing the release last failed, so it isn't retried
+	// on every run (e.g. when offline) until the cache expires again.
+	FailedAt time.Time `json:"failed_at,omitzero"`
+}
+
+// expired returns true if the cache should be refreshed, including after a
+// recent failure.
+func (c *updateCache) expired(ttl time.Duration) bool {
+	last := c.CheckedAt
+	if c.FailedAt.After(last) {
+		last = c.FailedAt
+	}
+	return time.Since(last) >= ttl
+}
+
+func (o *UpdateCheckOptions) readCache() *updateCache {
+	if o.CacheFile == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(o.CacheFile)
+	if err != nil {
+		return nil
+	}
+
+	cache := &updateCache{}
+	if err = json.Unmarshal(b, cache); err != nil || (cache.Release == nil && cache.FailedAt.IsZero()) {
+		return nil
+	}
+	return cache
+}
+
+func (o *UpdateCheckOptions) writeCache(release *Release) error {
+	return o.storeCache(&updateCache{CheckedAt: time.Now(), Release: release})
+}
+
+func (o *UpdateCheckOptions) storeCache(cache *updateCache) error {
+	if o.CacheFile == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(o.CacheFile), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(o.CacheFile, b, 0o600)
+}
+
+// fetch fetches the latest release from the source, and caches it.
+func (o *UpdateCheckOptions) fetch(ctx context.Context) (*Release, error) {
+	if o.Source == nil {
+		return nil, errors.New("no release source configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	release, err := o.Source.LatestRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return release, o.writeCache(release)
+}
+
+// CheckForUpdate returns the latest release if it is newer than the current
+// version, otherwise nil. Cached results are used if they haven't expired.
+// Returns nil without an error if update checks are disabled (see
+// [UpdateCheckOptions.DisableEnv]), or the current version isn't a valid
+// semantic version.
+func CheckForUpdate(ctx context.Context, version *Version, opts UpdateCheckOptions) (*Release, error) {
+	opts.setDefaults(version.AppInfo)
+
+	if opts.disabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	var release *Release
+
+	if cache := opts.readCache(); cache != nil && cache.Release != nil && time.Since(cache.CheckedAt) < opts.CacheTTL {
+		release = cache.Release
+	} else {
+		var err error
+		release, err = opts.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newerRelease(version, release), nil
+}
+
+// newerRelease returns the release if it is newer than the current version,
+// otherwise nil.
+func newerRelease(version *Version, release *Release) *Release {
+	if release == nil {
+		return nil
+	}
+
+	if c, err := version.Compare(release.Version); err != nil || c >= 0 {
+		return nil
+	}
+	return release
+}
+
+// WithUpdateCheck adds the update check plugin to the CLI, which compares the
+// current version against the latest release from a [ReleaseSource], printing a
+// notice to stderr (only when stderr is a TTY) when a newer version is available.
+//
+// The latest release is cached, and when the cache has expired, it is refreshed
+// before printing the notice, bounded by [UpdateCheckOptions.NoticeTimeout] so
+// the CLI isn't slowed down (falling back to the expired cache, if any). Update
+// checks are disabled when stderr isn't a TTY, when the current version isn't a
+// valid semantic version (e.g. development builds), when running in CI (CI env
+// var), or when the [UpdateCheckOptions.DisableEnv] env var is set. See
+// [UpdateCheckOptions] for defaults.
+func WithUpdateCheck[T any](opts UpdateCheckOptions) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Load() {
+			return
+		}
+		cli.kongOptions = append(cli.kongOptions, kong.WithAfterApply(func(kctx *kong.Context) error {
+			if initialized.Swap(true) {
+				return nil
+			}
+
+			opts.setDefaults(cli.app)
+
+			if opts.disabled() || opts.Source == nil || !cli.Term().StderrTTY() {
+				return nil
+			}
+
+			if _, err := cli.version.SemVer(); err != nil {
+				return nil //nolint:nilerr
+			}
+
+			logger := cli.logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+
+			if release := newerRelease(cli.version, opts.noticeRelease(logger)); release != nil {
+				printUpdateNotice(cli.errWriter(kctx), cli.version, release, cli.Term().StderrColor())
+			}
+			return nil
+		}))
+	}
+}
+
+// noticeRelease returns the cached latest release, refreshing it first if the
+// cache has expired, bounded by [UpdateCheckOptions.NoticeTimeout]. If the
+// refresh fails, the expired cache is used, and the failure is cached so the
+// refresh isn't retried until [UpdateCheckOptions.CacheTTL] has passed.
+func (o *UpdateCheckOptions) noticeRelease(logger *slog.Logger) *Release {
+	cache := o.readCache()
+	if cache == nil {
+		cache = &updateCache{}
+	} else if !cache.expired(o.CacheTTL) {
+		return cache.Release
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.NoticeTimeout)
+	defer cancel()
+
+	fresh, err := o.fetch(ctx)
+	if err != nil {
+		logger.Debug("failed to check for updates", "error", err)
+
+		cache.FailedAt = time.Now()
+		if err = o.storeCache(cache); err != nil {
+			logger.Debug("failed to cache update check failure", "error", err)
+		}
+		return cache.Release
+	}
+	return fresh
+}
+
+func printUpdateNotice(w io.Writer, version *Version, release *Release, color bool) {
+	notice := fmt.Sprintf(
+		"A new version of %s is available: %s -> %s",
+		filepath.Base(version.AppInfo.Name), version.AppInfo.Version, release.Version,
+	)
+	if color {
+		notice = "\x1b[33m" + notice + "\x1b[0m"
+	}
+
+	fmt.Fprintln(w, notice)
+	if release.URL != "" {
+		fmt.Fprintln(w, release.URL)
+	}
+}
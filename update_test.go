@@ -0,0 +1,239 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGithubReleaseSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/lrstanley/clix/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"tag_name": "v1.2.0",
+			"html_url": "https://github.com/lrstanley/clix/releases/tag/v1.2.0",
+			"assets": [{"name": "clix_linux_amd64", "browser_download_url": "https://example.com/clix_linux_amd64"}]
+		}`))
+	}))
+	defer srv.Close()
+
+	src := &GithubReleaseSource{Repo: "https://github.com/lrstanley/clix", BaseURL: srv.URL}
+
+	release, err := src.LatestRelease(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if release.Version != "v1.2.0" {
+		t.Fatalf("expected version v1.2.0, got %q", release.Version)
+	}
+	if len(release.Artifacts) != 1 || release.Artifacts[0].Name != "clix_linux_amd64" {
+		t.Fatalf("expected artifact from assets, got %#v", release.Artifacts)
+	}
+
+	if _, err = (&GithubReleaseSource{Repo: "invalid", BaseURL: srv.URL}).LatestRelease(t.Context()); err == nil {
+		t.Fatal("expected error for invalid repository")
+	}
+}
+
+func TestManifestReleaseSource(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(fn, []byte(`{"version": "v2.0.0"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := (&ManifestReleaseSource{URL: "file://" + fn}).LatestRelease(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Version != "v2.0.0" {
+		t.Fatalf("expected version v2.0.0, got %q", release.Version)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/local/manifest.json" {
+			_, _ = w.Write([]byte(`{"version": "v2.1.0", "checksums": "file:///etc/passwd"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"version": "v2.1.0", "artifacts": [{"name": "app", "url": "app.tar.gz"}]}`))
+	}))
+	defer srv.Close()
+
+	// Remote manifests can't reference local files.
+	_, err = (&ManifestReleaseSource{URL: srv.URL + "/local/manifest.json"}).LatestRelease(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "file:///etc/passwd") {
+		t.Fatalf("expected error for local URL in remote manifest, got %v", err)
+	}
+
+	release, err = (&ManifestReleaseSource{URL: srv.URL + "/releases/manifest.json"}).LatestRelease(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.URL + "/releases/app.tar.gz"; release.Artifacts[0].URL != want {
+		t.Fatalf("expected relative artifact URL to resolve to %q, got %q", want, release.Artifacts[0].URL)
+	}
+}
+
+type staticReleaseSource struct {
+	version string
+	calls   atomic.Int32
+}
+
+func (s *staticReleaseSource) LatestRelease(context.Context) (*Release, error) {
+	s.calls.Add(1)
+	return &Release{Version: s.version}, nil
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	t.Setenv("CI", "")
+	t.Setenv(DefaultUpdateCheckDisableEnv, "")
+
+	src := &staticReleaseSource{version: "v1.3.0"}
+	opts := UpdateCheckOptions{
+		Source:    src,
+		CacheFile: filepath.Join(t.TempDir(), "cache.json"),
+	}
+
+	version := &Version{AppInfo: &AppInfo{Name: "testapp", Version: "v1.2.0"}}
+
+	release, err := CheckForUpdate(t.Context(), version, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release == nil || release.Version != "v1.3.0" {
+		t.Fatalf("expected newer release v1.3.0, got %#v", release)
+	}
+
+	// Second check should be served from the cache.
+	if _, err = CheckForUpdate(t.Context(), version, opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := src.calls.Load(); n != 1 {
+		t.Fatalf("expected source to be called once, got %d", n)
+	}
+
+	// Expired cache should refetch.
+	b, _ := json.Marshal(&updateCache{CheckedAt: time.Now().Add(-48 * time.Hour), Release: &Release{Version: "v1.0.0"}})
+	if err = os.WriteFile(opts.CacheFile, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = CheckForUpdate(t.Context(), version, opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := src.calls.Load(); n != 2 {
+		t.Fatalf("expected source to be called again after cache expiry, got %d", n)
+	}
+
+	version.AppInfo.Version = "v1.3.0"
+	if release, _ = CheckForUpdate(t.Context(), version, opts); release != nil {
+		t.Fatalf("expected no update when up to date, got %#v", release)
+	}
+
+	version.AppInfo.Version = "v1.0.0"
+	t.Setenv("CI", "true")
+	if release, _ = CheckForUpdate(t.Context(), version, opts); release != nil {
+		t.Fatal("expected update check to be disabled in CI")
+	}
+}
+
+func TestUpdateCheckDisabled(t *testing.T) {
+	opts := UpdateCheckOptions{}
+	opts.setDefaults(nil)
+
+	tests := map[string]bool{"": false, "0": false, "false": false, "1": true, "yes": true, "on": true}
+	for value, want := range tests {
+		t.Setenv("CI", "")
+		t.Setenv(DefaultUpdateCheckDisableEnv, value)
+
+		if got := opts.disabled(); got != want {
+			t.Fatalf("expected disabled=%t for %s=%q, got %t", want, DefaultUpdateCheckDisableEnv, value, got)
+		}
+	}
+}
+
+type blockingReleaseSource struct{}
+
+func (blockingReleaseSource) LatestRelease(ctx context.Context) (*Release, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestUpdateNoticeRelease(t *testing.T) {
+	opts := UpdateCheckOptions{
+		Source:        blockingReleaseSource{},
+		CacheFile:     filepath.Join(t.TempDir(), "cache.json"),
+		NoticeTimeout: 10 * time.Millisecond,
+	}
+	opts.setDefaults(nil)
+
+	b, _ := json.Marshal(&updateCache{CheckedAt: time.Now().Add(-48 * time.Hour), Release: &Release{Version: "v1.0.0"}})
+	if err := os.WriteFile(opts.CacheFile, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Slow sources should be bounded, falling back to the expired cache.
+	start := time.Now()
+	if release := opts.noticeRelease(slog.Default()); release == nil || release.Version != "v1.0.0" {
+		t.Fatalf("expected expired cache to be used, got %#v", release)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected refresh to be bounded by the notice timeout, took %s", elapsed)
+	}
+
+	src := &staticReleaseSource{version: "v1.3.0"}
+	opts.Source = src
+
+	// The failure should be cached, so it isn't retried (and doesn't block) on
+	// every run.
+	if release := opts.noticeRelease(slog.Default()); release == nil || release.Version != "v1.0.0" || src.calls.Load() != 0 {
+		t.Fatalf("expected failure to be cached, got %#v (%d calls)", release, src.calls.Load())
+	}
+
+	b, _ = json.Marshal(&updateCache{
+		CheckedAt: time.Now().Add(-48 * time.Hour),
+		Release:   &Release{Version: "v1.0.0"},
+		FailedAt:  time.Now().Add(-48 * time.Hour),
+	})
+	if err := os.WriteFile(opts.CacheFile, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if release := opts.noticeRelease(slog.Default()); release == nil || release.Version != "v1.3.0" {
+		t.Fatalf("expected refreshed release, got %#v", release)
+	}
+
+	// Refreshed release should now be served from the cache.
+	if release := opts.noticeRelease(slog.Default()); release == nil || release.Version != "v1.3.0" || src.calls.Load() != 1 {
+		t.Fatalf("expected cached release, got %#v (%d calls)", release, src.calls.Load())
+	}
+}
+
+func TestPrintUpdateNotice(t *testing.T) {
+	var buf strings.Builder
+	printUpdateNotice(
+		&buf,
+		&Version{AppInfo: &AppInfo{Name: "github.com/lrstanley/testapp", Version: "v1.0.0"}},
+		&Release{Version: "v1.1.0", URL: "https://example.com/v1.1.0"},
+		false,
+	)
+
+	want := "A new version of testapp is available: v1.0.0 -> v1.1.0\nhttps://example.com/v1.1.0\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alecthomas/kong"
+)
+
+// ExitCodeVerifyFailed is the exit code used by the "version verify" command
+// when one or more checks fail. See [WithVersionVerifyPlugin].
+const ExitCodeVerifyFailed = 4
+
+// VerifyPolicy describes the expected build properties of a release binary. See
+// [Version.Verify].
+type VerifyPolicy struct {
+	// RequireClean requires the binary to be built from a VCS checkout without
+	// uncommitted changes (vcs.modified=false).
+	RequireClean bool `json:"require_clean"`
+
+	// RequireTrimpath requires the binary to be built with -trimpath.
+	RequireTrimpath bool `json:"require_trimpath"`
+
+	// RequireCGODisabled requires the binary to be built with CGO_ENABLED=0.
+	RequireCGODisabled bool `json:"require_cgo_disabled"`
+
+	// RevisionLengths are the allowed lengths of the (hex-encoded) VCS revision,
+	// e.g. 40 for SHA-1, or 64 for SHA-256. If empty, the revision isn't checked.
+	RevisionLengths []int `json:"revision_lengths,omitempty"`
+
+	// DisallowLocalReplacements requires no dependencies to be replaced by local
+	// (filesystem) modules. See [Version.HasLocalReplacements].
+	DisallowLocalReplacements bool `json:"disallow_local_replacements"`
+}
+
+// DefaultVerifyPolicy returns a reasonable policy for release builds, which can
+// be further customized. CGO isn't checked, as it's only required to be disabled
+// for some projects.
+func DefaultVerifyPolicy() VerifyPolicy {
+	return VerifyPolicy{
+		RequireClean:              true,
+		RequireTrimpath:           true,
+		RevisionLengths:           []int{40, 64},
+		DisallowLocalReplacements: true,
+	}
+}
+
+// VerifyResult is the result of a single check from [Version.Verify].
+type VerifyResult struct {
+	Check   string `json:"check"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// Verify checks the embedded build information against the provided policy,
+// returning the result of each check enabled by the policy.
+func (v *Version) Verify(policy VerifyPolicy) []VerifyResult {
+	var results []VerifyResult
+
+	if policy.RequireClean {
+		modified := v.GetSetting("vcs.modified", "")
+		results = append(results, VerifyResult{
+			Check:   "vcs.modified",
+			Passed:  modified == "false",
+			Message: describeSetting("vcs.modified", modified, "false"),
+		})
+	}
+
+	if policy.RequireTrimpath {
+		trimpath := v.GetSetting("-trimpath", "")
+		results = append(results, VerifyResult{
+			Check:   "trimpath",
+			Passed:  trimpath == "true",
+			Message: describeSetting("-trimpath", trimpath, "true"),
+		})
+	}
+
+	if policy.RequireCGODisabled {
+		cgo := v.GetSetting("CGO_ENABLED", "")
+		results = append(results, VerifyResult{
+			Check:   "cgo",
+			Passed:  cgo == "0",
+			Message: describeSetting("CGO_ENABLED", cgo, "0"),
+		})
+	}
+
+	if len(policy.RevisionLengths) > 0 {
+		rev := v.GetSetting("vcs.revision", v.AppInfo.Commit)
+		_, err := hex.DecodeString(rev)
+
+		lengths := make([]string, 0, len(policy.RevisionLengths))
+		for _, l := range policy.RevisionLengths {
+			lengths = append(lengths, strconv.Itoa(l))
+		}
+
+		results = append(results, VerifyResult{
+			Check:  "vcs.revision",
+			Passed: err == nil && slices.Contains(policy.RevisionLengths, len(rev)),
+			Message: fmt.Sprintf(
+				"vcs.revision is %q (length %d), expected hex of length %s",
+				rev, len(rev), strings.Join(lengths, " or "),
+			),
+		})
+	}
+
+	if policy.DisallowLocalReplacements {
+		var local []string
+		for _, m := range v.LocalReplacements() {
+			local = append(local, m.chain())
+		}
+
+		msg := "no local module replacements"
+		if len(local) > 0 {
+			msg = "local module replacements: " + strings.Join(local, ", ")
+		}
+
+		results = append(results, VerifyResult{
+			Check:   "replacements",
+			Passed:  len(local) == 0,
+			Message: msg,
+		})
+	}
+
+	return results
+}
+
+func describeSetting(key, value, expected string) string {
+	if value == "" {
+		return fmt.Sprintf("%s is not set, expected %q", key, expected)
+	}
+	return fmt.Sprintf("%s is %q, expected %q", key, value, expected)
+}
+
+// WithVersionVerifyPlugin adds a hidden "version-verify" command to the CLI,
+// which checks the embedded build information of the running binary against the
+// provided policy (see [Version.Verify] and [DefaultVerifyPolicy]), printing the
+// result of each check, and exiting with [ExitCodeVerifyFailed] if any checks
+// fail. Useful for release pipelines, rather than parsing "go version -m"
+// output. Like the markdown plugin, it's invoked before kong applies additional
+// restrictions, so it ignores any other required flags. The command name doesn't
+// clash with a "version" command provided by the application.
+func WithVersionVerifyPlugin[T any](policy VerifyPolicy) Option[T] {
+	var initialized atomic.Bool
+	return func(cli *CLI[T]) {
+		if initialized.Swap(true) {
+			return
+		}
+
+		cli.kongOptions = append(
+			cli.kongOptions, kong.DynamicCommand(
+				"version-verify",
+				"verify the build information of the binary against the release policy",
+				"",
+				&VersionVerifyCommand{policy: policy},
+				"hidden",
+			),
+		)
+	}
+}
+
+// VersionVerifyCommand is the "version-verify" command. See [WithVersionVerifyPlugin].
+type VersionVerifyCommand struct {
+	JSON bool `name:"json" help:"output results in JSON format"`
+
+	policy VerifyPolicy
+}
+
+func (c *VersionVerifyCommand) BeforeReset(kctx *kong.Context, path *kong.Path, version *Version) error {
+	asJSON, _ := strconv.ParseBool(earlyFlagValue(kctx, path.Command, "json"))
+
+	results := version.Verify(c.policy)
+
+	if err := writeVerifyResults(kctx.Stdout, results, asJSON); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if !r.Passed {
+			kctx.Exit(ExitCodeVerifyFailed)
+			return nil
+		}
+	}
+
+	kctx.Exit(0)
+	return nil
+}
+
+func writeVerifyResults(w io.Writer, results []VerifyResult, asJSON bool) error {
+	if asJSON {
+		return renderJSON(w, results)
+	}
+
+	failed := 0
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+			failed++
+		}
+
+		if _, err := fmt.Fprintf(w, "%s  %-13s %s\n", status, r.Check, r.Message); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d/%d checks passed\n", len(results)-failed, len(results))
+	return err
+}
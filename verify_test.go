@@ -0,0 +1,93 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestVersionVerify(t *testing.T) {
+	version := &Version{
+		AppInfo: &AppInfo{Name: "testapp", Version: "v1.0.0"},
+		Settings: []BuildSetting{
+			{Key: "-trimpath", Value: "true"},
+			{Key: "CGO_ENABLED", Value: "1"},
+			{Key: "vcs.revision", Value: strings.Repeat("a", 40)},
+			{Key: "vcs.modified", Value: "false"},
+		},
+	}
+
+	policy := DefaultVerifyPolicy()
+	policy.RequireCGODisabled = true
+
+	results := version.Verify(policy)
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+
+	for _, r := range results {
+		if r.Passed == (r.Check == "cgo") {
+			t.Fatalf("unexpected result for %q: %#v", r.Check, r)
+		}
+	}
+
+	version.Settings[2].Value = "not-a-sha"
+	version.Dependencies = []Module{{Path: "github.com/example/foo", Version: "v1.0.0", Replace: &Module{Path: "../foo"}}}
+
+	for _, r := range version.Verify(DefaultVerifyPolicy()) {
+		if (r.Check == "vcs.revision" || r.Check == "replacements") && r.Passed {
+			t.Fatalf("expected %q check to fail: %#v", r.Check, r)
+		}
+	}
+}
+
+func TestVersionVerifyCommand(t *testing.T) {
+	type Flags struct {
+		Required string `name:"required" required:""`
+
+		// Applications can still provide their own "version" command.
+		Version struct{} `cmd:"" help:"print the version"`
+	}
+
+	oldArgs := os.Args
+	t.Cleanup(func() { os.Args = oldArgs })
+	os.Args = []string{"testapp", "version-verify", "--json"}
+
+	var buf bytes.Buffer
+	code := -1
+
+	New(
+		WithKongOptions[Flags](
+			kong.Writers(&buf, &bytes.Buffer{}),
+			kong.Exit(func(c int) {
+				// Parsing continues after exit, so only record the first exit.
+				if code == -1 {
+					code = c
+				}
+			}),
+		),
+		WithAppInfo[Flags](AppInfo{Name: "testapp", Version: "v1.0.0", Commit: "unknown"}),
+		WithVersionVerifyPlugin[Flags](VerifyPolicy{RevisionLengths: []int{40}}),
+	)
+
+	if code != ExitCodeVerifyFailed {
+		t.Fatalf("expected exit code %d, got %d", ExitCodeVerifyFailed, code)
+	}
+
+	var results []VerifyResult
+	if err := json.NewDecoder(&buf).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Check != "vcs.revision" || results[0].Passed {
+		t.Fatalf("expected failed revision check, got %#v", results)
+	}
+}
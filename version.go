@@ -6,18 +6,21 @@ package clix
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"strings"
 	"sync/atomic"
+	"text/template"
+
+	"github.com/alecthomas/kong"
 )
 
 // WithVersionPlugin adds the version plugin to the CLI. This includes flags
-// for --version, --version-json, etc.
+// for --version, --version-json, --version-format, etc.
 func WithVersionPlugin[T any]() Option[T] {
 	var initialized atomic.Bool
 	return func(cli *CLI[T]) {
@@ -28,34 +31,127 @@ func WithVersionPlugin[T any]() Option[T] {
 	}
 }
 
+// WithNonSensitiveVersion makes the version plugin only output non-sensitive
+// version information (see [NonSensitiveVersion]), which excludes build
+// settings and dependencies. Useful for public-facing builds.
+func WithNonSensitiveVersion[T any]() Option[T] {
+	return func(cli *CLI[T]) {
+		cli.version.nonSensitive = true
+	}
+}
+
 // GetVersion returns the version information for the CLI, which will be populated
 // after parsing.
 func (cli *CLI[T]) GetVersion() *Version {
 	return cli.version
 }
 
+// Named formats supported by the --version and --version-format flags, in
+// addition to Go templates.
+const (
+	VersionFormatShort = "short" // Only the version.
+	VersionFormatFull  = "full"  // Version, build information, links, build settings and dependencies.
+	VersionFormatJSON  = "json"  // Same as full, but in JSON format.
+	VersionFormatYAML  = "yaml"  // Same as full, but in YAML format.
+)
+
 type VersionPlugin struct {
-	Version     VersionFlag     `short:"v" name:"version" help:"prints version information and exits"`
-	VersionJSON VersionJSONFlag `name:"version-json" help:"prints version information in JSON format and exits"`
+	Version       VersionOutputFlag `short:"v" name:"version" help:"prints version information and exits, optionally in the provided format (e.g. --version=json, see --version-format)"`
+	VersionJSON   VersionJSONFlag   `name:"version-json" help:"prints version information in JSON format and exits"`
+	VersionFormat VersionFormatFlag `name:"version-format" placeholder:"FORMAT" help:"prints version information in the provided format (short, full, json, yaml) or using a Go template, and exits (e.g. '{{.AppInfo.Version}}')"`
 }
 
+// VersionFlag prints version information in the [VersionFormatFull] format,
+// and exits. See also [VersionOutputFlag], which also accepts a format.
 type VersionFlag bool
 
-func (v VersionFlag) BeforeReset(ver *Version) error {
-	fmt.Println(ver.String()) //nolint:forbidigo
-	os.Exit(0)
+func (v VersionFlag) BeforeReset(kctx *kong.Context, ver *Version) error {
+	if err := ver.write(kctx.Stdout, VersionFormatFull, terminalFromContext(kctx).StdoutColor()); err != nil {
+		return err
+	}
+	kctx.Exit(0)
 	return nil
 }
 
+// VersionOutputFlag prints version information and exits. Like a boolean flag,
+// it can be provided without a value (e.g. --version), which uses
+// [VersionFormatFull], or with a value (e.g. --version=json), which supports the
+// same formats as [VersionFormatFlag].
+type VersionOutputFlag string
+
+// Decode implements [kong.MapperValue].
+func (v *VersionOutputFlag) Decode(ctx *kong.DecodeContext) error {
+	if ctx.Scan.Peek().Type == kong.FlagValueToken {
+		return ctx.Scan.PopValueInto("format", (*string)(v))
+	}
+	*v = VersionFormatFull
+	return nil
+}
+
+// IsBool implements [kong.BoolMapperValue], so a value is optional.
+func (v *VersionOutputFlag) IsBool() bool {
+	return true
+}
+
+func (v VersionOutputFlag) BeforeReset(kctx *kong.Context, path *kong.Path, ver *Version) error {
+	format, _ := kctx.FlagValue(path.Flag).(VersionOutputFlag)
+	return writeVersionFormat(kctx, path.Flag.Name, string(format), ver)
+}
+
 type VersionJSONFlag bool
 
-func (v VersionJSONFlag) BeforeReset(ver *Version) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "    ")
-	if err := enc.Encode(ver); err != nil {
-		panic(err)
+func (v VersionJSONFlag) BeforeReset(kctx *kong.Context, ver *Version) error {
+	if err := ver.write(kctx.Stdout, VersionFormatJSON, false); err != nil {
+		return err
+	}
+	kctx.Exit(0)
+	return nil
+}
+
+// VersionFormatFlag prints version information in one of the named formats
+// ([VersionFormatShort], [VersionFormatFull], [VersionFormatJSON] or
+// [VersionFormatYAML]), or using a Go [text/template], and exits. Templates are
+// executed against [Version] (or [NonSensitiveVersion] when using
+// [WithNonSensitiveVersion]), and have access to the same helper functions as the
+// markdown templates.
+type VersionFormatFlag string
+
+func (v VersionFormatFlag) BeforeReset(kctx *kong.Context, path *kong.Path, ver *Version) error {
+	tmpl, _ := kctx.FlagValue(path.Flag).(VersionFormatFlag)
+	return writeVersionFormat(kctx, path.Flag.Name, string(tmpl), ver)
+}
+
+// writeVersionFormat writes the version information in the named format, or
+// using the template, and exits. name is the flag name, used in errors.
+func writeVersionFormat(kctx *kong.Context, name, tmpl string, ver *Version) error {
+	switch format := strings.ToLower(strings.TrimSpace(tmpl)); format {
+	case VersionFormatShort, VersionFormatFull, VersionFormatJSON, VersionFormatYAML:
+		err := ver.write(kctx.Stdout, format, format == VersionFormatFull && terminalFromContext(kctx).StdoutColor())
+		if err != nil {
+			return err
+		}
+		kctx.Exit(0)
+		return nil
+	}
+
+	t, err := template.New("version").Funcs(tmplFuncMap).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("--%s: invalid template: %w", name, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = t.Execute(buf, ver.output()); err != nil {
+		return fmt.Errorf("--%s: %w", name, err)
+	}
+
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString("\n")
+	}
+
+	if _, err = kctx.Stdout.Write(buf.Bytes()); err != nil {
+		return err
 	}
-	os.Exit(0)
+	kctx.Exit(0)
 	return nil
 }
 
@@ -67,12 +163,72 @@ type Module struct {
 	Replace *Module `json:"replaces,omitempty"` // replaced by this module
 }
 
-func (m Module) String() string {
+// newModule converts a module from the build info, including any replacements.
+func newModule(m *debug.Module) Module {
+	mod := Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+	}
+
 	if m.Replace != nil {
+		r := newModule(m.Replace)
+		mod.Replace = &r
+	}
+
+	return mod
+}
+
+// Resolved returns the module which is actually used in the build, following any
+// replacements.
+func (m Module) Resolved() Module {
+	for m.Replace != nil {
 		m = *m.Replace
 	}
+	return m
+}
+
+// IsLocal returns true if the module is a local (filesystem) module, which is
+// the case for replacements using a relative or absolute path.
+func (m Module) IsLocal() bool {
+	return m.Version == "" && (strings.HasPrefix(m.Path, ".") || filepath.IsAbs(m.Path))
+}
+
+// HasLocalReplacement returns true if the module is replaced by a local module.
+func (m Module) HasLocalReplacement() bool {
+	return m.Replace != nil && m.Resolved().IsLocal()
+}
+
+// pathVersion returns the module path and version, separated by " :: ".
+func (m Module) pathVersion() string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + " :: " + m.Version
+}
 
-	return fmt.Sprintf("%s :: %s :: %s", m.Sum, m.Path, m.Version)
+// sum returns the checksum of the resolved module.
+func (m Module) sum() string {
+	if sum := m.Resolved().Sum; sum != "" {
+		return sum
+	}
+	return "unknown" //nolint:goconst
+}
+
+// chain returns the path and version of the module, and any replacements, in
+// the format "orig => replacement".
+func (m Module) chain() string {
+	s := m.pathVersion()
+	for r := m.Replace; r != nil; r = r.Replace {
+		s += " => " + r.pathVersion()
+	}
+	return s
+}
+
+// String returns the checksum, path and version of the module, and if replaced,
+// the replacement in the format "orig => replacement".
+func (m Module) String() string {
+	return m.sum() + " :: " + m.chain()
 }
 
 // BuildSetting describes a setting that may be used to understand how the
@@ -91,6 +247,9 @@ func (s BuildSetting) String() string {
 
 // Version represents the version information for the CLI.
 type Version struct {
+	nonSensitive bool   // Only output non-sensitive information. See [WithNonSensitiveVersion].
+	mainPath     string // Main module path from the build info, if available.
+
 	AppInfo      *AppInfo       `json:"app_info,omitempty"`       // Application information.
 	Settings     []BuildSetting `json:"build_settings,omitempty"` // Other information about the build.
 	Dependencies []Module       `json:"dependencies,omitempty"`   // Module dependencies.
@@ -121,6 +280,36 @@ func (v *Version) NonSensitive() *NonSensitiveVersion {
 	}
 }
 
+// output returns the value used when outputting version information, respecting
+// [WithNonSensitiveVersion].
+func (v *Version) output() any {
+	if v.nonSensitive {
+		return v.NonSensitive()
+	}
+	return v
+}
+
+// write writes the version information to w in the provided format. See the
+// VersionFormat* constants for supported formats.
+func (v *Version) write(w io.Writer, format string, color bool) error {
+	switch format {
+	case VersionFormatShort:
+		_, err := fmt.Fprintln(w, v.AppInfo.Version)
+		return err
+	case VersionFormatJSON:
+		return renderJSON(w, v.output())
+	case VersionFormatYAML:
+		return renderYAML(w, v.output())
+	default:
+		out := v.String()
+		if v.nonSensitive {
+			out = v.stringBase()
+		}
+		_, err := fmt.Fprintln(w, styleHeadings(out, color))
+		return err
+	}
+}
+
 // GetSetting returns the value of the setting with the given key, otherwise
 // defaults to defaultValue.
 func (v *Version) GetSetting(key, defaultValue string) string {
@@ -148,6 +337,9 @@ func (v *Version) stringBase() string {
 	}
 	fmt.Fprintf(w, "  build commit: %s\n", v.AppInfo.Commit)
 	fmt.Fprintf(w, "    build date: %s\n", v.AppInfo.Date)
+	if v.AppInfo.Branch != "" {
+		fmt.Fprintf(w, "  build branch: %s\n", v.AppInfo.Branch)
+	}
 	fmt.Fprintf(w, "    go version: %s %s/%s\n", v.GoVersion, v.OS, v.Arch)
 
 	if len(v.AppInfo.Links) > 0 {
@@ -194,18 +386,29 @@ func (v *Version) String() string {
 
 	fmt.Fprintf(w, "\ndependencies:\n")
 	for _, m := range v.Dependencies {
-		if m.Replace != nil {
-			m = *m.Replace
-		}
+		fmt.Fprintf(w, "  %47s :: %s\n", m.sum(), m.chain())
+	}
 
-		if m.Sum == "" {
-			m.Sum = "unknown" //nolint:goconst
-		}
+	return w.String()
+}
 
-		fmt.Fprintf(w, "  %47s :: %s :: %s\n", m.Sum, m.Path, m.Version)
+// LocalReplacements returns the dependencies which are replaced by local
+// (filesystem) modules.
+func (v *Version) LocalReplacements() []Module {
+	var mods []Module
+	for _, m := range v.Dependencies {
+		if m.HasLocalReplacement() {
+			mods = append(mods, m)
+		}
 	}
+	return mods
+}
 
-	return w.String()
+// HasLocalReplacements returns true if any dependencies are replaced by local
+// (filesystem) modules, which generally shouldn't be the case for release
+// builds.
+func (v *Version) HasLocalReplacements() bool {
+	return len(v.LocalReplacements()) > 0
 }
 
 // GetVersionInfo returns the version information for the CLI.
@@ -218,6 +421,20 @@ func GetVersionInfo(app *AppInfo) *Version {
 		Arch:      runtime.GOARCH,
 	}
 
+	// ldflags take precedence over VCS and module information. See ldflags.go.
+	if v.AppInfo.Version == "" {
+		v.AppInfo.Version = buildVersion
+	}
+	if v.AppInfo.Commit == "" {
+		v.AppInfo.Commit = buildCommit
+	}
+	if v.AppInfo.Date == "" {
+		v.AppInfo.Date = buildDate
+	}
+	if v.AppInfo.Branch == "" {
+		v.AppInfo.Branch = buildBranch
+	}
+
 	build, ok := debug.ReadBuildInfo()
 	if ok {
 		if v.Settings == nil {
@@ -233,14 +450,12 @@ func GetVersionInfo(app *AppInfo) *Version {
 		if v.Dependencies == nil {
 			v.Dependencies = make([]Module, 0, len(build.Deps))
 			for _, dep := range build.Deps {
-				v.Dependencies = append(v.Dependencies, Module{
-					Path:    dep.Path,
-					Version: dep.Version,
-					Sum:     dep.Sum,
-				})
+				v.Dependencies = append(v.Dependencies, newModule(dep))
 			}
 		}
 
+		v.mainPath = build.Main.Path
+
 		if v.AppInfo.Name == "" {
 			v.AppInfo.Name = build.Main.Path
 		}
@@ -258,6 +473,8 @@ func GetVersionInfo(app *AppInfo) *Version {
 		}
 	}
 
+	v.Settings = append(v.Settings, ldflagsSettings()...)
+
 	if v.AppInfo.Name == "" {
 		v.AppInfo.Name = v.Command
 	}
@@ -5,8 +5,12 @@
 package clix
 
 import (
+	"os"
+	"runtime/debug"
 	"strings"
 	"testing"
+
+	"github.com/alecthomas/kong"
 )
 
 func TestGetVersionInfo(t *testing.T) {
@@ -34,3 +38,160 @@ func TestGetVersionInfo(t *testing.T) {
 		}
 	}
 }
+
+func TestGetVersionInfoLdflags(t *testing.T) {
+	old := []string{buildVersion, buildCommit, buildDate, buildBranch, buildMetadata}
+	t.Cleanup(func() {
+		buildVersion, buildCommit, buildDate, buildBranch, buildMetadata = old[0], old[1], old[2], old[3], old[4]
+	})
+
+	buildVersion = "v2.0.0"
+	buildCommit = "deadbeef"
+	buildDate = "2025-01-01T00:00:00Z"
+	buildBranch = "release"
+	buildMetadata = "pipeline=1234, builder = ci,,invalid"
+
+	version := GetVersionInfo(&AppInfo{Commit: "explicit"})
+
+	if version.AppInfo.Version != "v2.0.0" || version.AppInfo.Date != "2025-01-01T00:00:00Z" {
+		t.Fatalf("expected ldflags to be used, got %#v", version.AppInfo)
+	}
+
+	if version.AppInfo.Commit != "explicit" {
+		t.Fatalf("expected explicit app info to take precedence over ldflags, got %q", version.AppInfo.Commit)
+	}
+
+	if !strings.Contains(version.String(), "build branch: release") {
+		t.Fatalf("expected branch in version output, got:\n%s", version.String())
+	}
+
+	for key, want := range map[string]string{"pipeline": "1234", "builder": "ci", "invalid": ""} {
+		if got := version.GetSetting(key, "missing"); got != want {
+			t.Fatalf("expected setting %q to be %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestModuleReplacements(t *testing.T) {
+	mod := newModule(&debug.Module{
+		Path:    "github.com/example/foo",
+		Version: "v1.0.0",
+		Replace: &debug.Module{
+			Path:    "github.com/fork/foo",
+			Version: "v1.0.1",
+			Sum:     "h1:abc=",
+			Replace: &debug.Module{Path: "../foo"},
+		},
+	})
+
+	// Local modules have no checksum.
+	if want := "unknown :: github.com/example/foo :: v1.0.0 => github.com/fork/foo :: v1.0.1 => ../foo"; mod.String() != want {
+		t.Fatalf("unexpected module string: %q", mod.String())
+	}
+
+	if r := mod.Resolved(); r.Path != "../foo" || !r.IsLocal() {
+		t.Fatalf("expected resolved module to be the local replacement, got %#v", r)
+	}
+
+	version := &Version{
+		AppInfo:      &AppInfo{Name: "testapp", Version: "v1.0.0"},
+		Dependencies: []Module{{Path: "github.com/example/bar", Version: "v1.0.0", Sum: "h1:def="}},
+	}
+
+	if version.HasLocalReplacements() {
+		t.Fatal("expected no local replacements")
+	}
+
+	version.Dependencies = append(version.Dependencies, mod)
+	if !version.HasLocalReplacements() || len(version.LocalReplacements()) != 1 {
+		t.Fatal("expected local replacement to be detected")
+	}
+
+	if !strings.Contains(version.String(), "github.com/example/foo :: v1.0.0 => github.com/fork/foo :: v1.0.1 => ../foo") {
+		t.Fatalf("expected replacement in version output, got:\n%s", version.String())
+	}
+
+	var buf strings.Builder
+	if err := version.write(&buf, VersionFormatJSON, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"replaces": {`) {
+		t.Fatalf("expected replacement in JSON output, got:\n%s", buf.String())
+	}
+}
+
+func TestVersionPluginFormats(t *testing.T) {
+	type Flags struct{}
+
+	tests := []struct {
+		args         []string
+		nonSensitive bool
+		expected     []string
+		unexpected   []string
+	}{
+		{args: []string{"--version-format=short"}, expected: []string{"v1.2.3\n"}, unexpected: []string{"build commit"}},
+		{args: []string{"-v"}, expected: []string{"example-name", "build options:", "dependencies:"}},
+		{args: []string{"--version-format=json"}, expected: []string{`"build_version": "v1.2.3"`, `"go_version"`}},
+		{args: []string{"--version-format=yaml"}, expected: []string{"build_version: v1.2.3", "go_version:"}},
+		{args: []string{"--version-json"}, expected: []string{`"build_version": "v1.2.3"`}},
+		{args: []string{"--version=short"}, expected: []string{"v1.2.3\n"}, unexpected: []string{"build commit"}},
+		{args: []string{"--version=full"}, expected: []string{"example-name", "build options:"}},
+		{args: []string{"--version=json"}, expected: []string{`"build_version": "v1.2.3"`}},
+		{args: []string{"--version=yaml"}, expected: []string{"build_version: v1.2.3"}},
+		{args: []string{"--version={{ .AppInfo.Version }}"}, expected: []string{"v1.2.3\n"}, unexpected: []string{"example-name"}},
+		{args: []string{"--version-format", "{{ .AppInfo.Name }}@{{ .AppInfo.Version }}"}, expected: []string{"example-name@v1.2.3\n"}},
+		{
+			args:         []string{"--version-format=json"},
+			nonSensitive: true,
+			expected:     []string{`"build_version": "v1.2.3"`},
+			unexpected:   []string{"build_settings", "dependencies"},
+		},
+		{
+			args:         []string{"--version"},
+			nonSensitive: true,
+			expected:     []string{"example-name"},
+			unexpected:   []string{"build options:", "dependencies:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var buf strings.Builder
+			var exited bool
+
+			oldArgs := os.Args
+			t.Cleanup(func() { os.Args = oldArgs })
+			os.Args = append([]string{"testapp"}, tt.args...)
+
+			opts := []Option[Flags]{
+				WithKongOptions[Flags](
+					kong.Writers(&buf, &buf),
+					kong.Exit(func(int) { exited = true }),
+				),
+				WithAppInfo[Flags](AppInfo{Name: "example-name", Version: "v1.2.3"}),
+				WithVersionPlugin[Flags](),
+			}
+			if tt.nonSensitive {
+				opts = append(opts, WithNonSensitiveVersion[Flags]())
+			}
+
+			New(opts...)
+
+			if !exited {
+				t.Fatal("expected version flag to exit")
+			}
+
+			out := buf.String()
+			for _, e := range tt.expected {
+				if !strings.Contains(out, e) {
+					t.Fatalf("expected %q to be in version output, got:\n%s", e, out)
+				}
+			}
+			for _, e := range tt.unexpected {
+				if strings.Contains(out, e) {
+					t.Fatalf("expected %q to not be in version output, got:\n%s", e, out)
+				}
+			}
+		})
+	}
+}